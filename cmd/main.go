@@ -2,67 +2,377 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	dapi "github.com/GalahadKingsman/messenger_dialog/pkg/messenger_dialog_api"
 	uapi "github.com/GalahadKingsman/messenger_users/pkg/messenger_users_api"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"log"
+	"log/slog"
+	"messenger_frontend/internal/admin"
+	"messenger_frontend/internal/audit"
+	"messenger_frontend/internal/config"
 	"messenger_frontend/internal/handlers"
+	"messenger_frontend/internal/health"
+	"messenger_frontend/internal/jwt"
+	"messenger_frontend/internal/lifecycle"
+	"messenger_frontend/internal/limiter"
+	"messenger_frontend/internal/logging"
+	"messenger_frontend/internal/metrics"
 	"messenger_frontend/internal/middleware"
+	"messenger_frontend/internal/openapi"
+	"messenger_frontend/internal/pagination"
 	"messenger_frontend/internal/storage"
+	"messenger_frontend/internal/tracing"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 )
 
+// version подставляется при сборке: go build -ldflags "-X main.version=1.2.3"
+var version = "dev"
+
 func main() {
-	ctx := context.Background()
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(os.Args[2:]))
+	}
+
+	logLevel := new(slog.LevelVar)
+	slog.SetDefault(logging.New(os.Stderr, logLevel))
+
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+	// Уровень уже проверен config.Validate
+	_ = logLevel.UnmarshalText([]byte(cfg.Log.Level))
+
+	r := newReloader(os.Args[0], os.Args[1:], cfg)
+	r.logLevel = logLevel
+	run(r)
+}
+
+// fatal пишет ошибку в лог и завершает процесс.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// runConfigCommand обрабатывает "api_gateway config check": проверяет конфигурацию
+// и печатает итоговые значения со скрытыми секретами.
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "usage: api_gateway config check [-config path] [flags]")
+		return 2
+	}
+	cfg, err := config.Load("config check", args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config is invalid:\n%v\n", err)
+		return 1
+	}
+	out, err := cfg.Redacted()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to print config: %v\n", err)
+		return 1
+	}
+	fmt.Print(string(out))
+	fmt.Fprintln(os.Stderr, "config is valid")
+	return 0
+}
+
+func run(reloader *reloader) {
+	cfg := reloader.config()
 
-	storage.InitRedis()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, version)
+	if err != nil {
+		fatal("failed to set up tracing", err)
+	}
+
+	jwt.SetSecret([]byte(cfg.JWT.Secret.Value()))
+	rdb, err := storage.NewRedis(ctx, cfg.Redis)
+	if err != nil {
+		fatal("failed to connect to Redis", err)
 	}
 
 	// Подключение к dialog-сервису
-	dialogsConn, err := grpc.DialContext(ctx, "dialog_service:9001", opts...)
+	dialogsConn, dialogsBalancing, err := dialUpstream(ctx, "dialogs", cfg.Upstreams.Dialogs)
 	if err != nil {
-		log.Fatalf("не удалось подключиться к dialogs gRPC: %v", err)
+		fatal("failed to connect to dialogs gRPC", err)
 	}
-	defer dialogsConn.Close()
 	dialogsClient := dapi.NewDialogServiceClient(dialogsConn)
 
 	// Подключение к users-сервису
-	usersConn, err := grpc.DialContext(ctx, "users_service:9000", opts...)
+	usersConn, usersBalancing, err := dialUpstream(ctx, "users", cfg.Upstreams.Users)
 	if err != nil {
-		log.Fatalf("не удалось подключиться к users gRPC: %v", err)
+		fatal("failed to connect to users gRPC", err)
 	}
-	defer usersConn.Close()
 	usersClient := uapi.NewUserServiceClient(usersConn)
 
+	auditLog, closeAudit, err := newAuditLog(cfg.Audit, rdb)
+	if err != nil {
+		fatal("failed to open audit log", err)
+	}
+
 	mux := http.NewServeMux()
+	drainer := lifecycle.NewDrainer()
 
-	dialogHandler := handlers.NewDialogHandlerService(dialogsClient)
+	// Без отдельного ключа курсоры подписываются ключом, выведенным из секрета JWT
+	cursorSecret := cfg.API.CursorSecret
+	if cursorSecret == "" {
+		cursorSecret = cfg.JWT.Secret
+	}
+	dialogHandler := handlers.NewDialogHandlerService(dialogsClient, rdb)
+	dialogHandler.Pages = pagination.New([]byte(cursorSecret.Value()), cfg.API.DefaultPageSize, cfg.API.MaxPageSize)
+	dialogHandler.Shutdown = drainer.Context()
 	dialogHandler.RegisterHandlers(mux)
 
-	userHandler := handlers.NewUserHandlerService(usersClient, storage.Rdb)
+	userHandler := handlers.NewUserHandlerService(usersClient, rdb)
+	userHandler.Audit = auditLog
 	userHandler.RegisterHandlers(mux)
 
-	notificationHandler := handlers.NewNotificationHandler("http://notifications:8082/notifications")
+	// Старые маршруты без версии остаются до даты отключения и помечаются заголовками Deprecation и Sunset
+	deprecation := middleware.Deprecation{Since: cfg.API.LegacyDeprecated, Sunset: cfg.API.LegacySunset}
+	if !deprecation.Sunset.IsZero() && deprecation.Sunset.Before(time.Now()) {
+		slog.Warn("api.legacy_sunset is in the past, clients are told the legacy routes are gone", "sunset", deprecation.Sunset)
+	}
+	dialogHandler.RegisterLegacyHandlers(mux, deprecation)
+	userHandler.RegisterLegacyHandlers(mux, deprecation)
+
+	notificationHandler := handlers.NewNotificationHandler(cfg.Upstreams.Notifications)
+	notificationHandler.Shutdown = drainer.Done()
 	notificationHandler.RegisterHandlers(mux)
 
-	protectedMux := middleware.JWTAuthMiddleware(mux)
+	rootMux := http.NewServeMux()
+
+	// Метки маршрутов берутся из шаблонов mux, а не из сырого пути; метод из шаблона отбрасывается
+	routePattern := func(r *http.Request) string {
+		_, pattern := rootMux.Handler(r)
+		if pattern == "" || pattern == "/" {
+			_, pattern = mux.Handler(r)
+		}
+		if _, path, ok := strings.Cut(pattern, " "); ok {
+			return path
+		}
+		return pattern
+	}
+
+	// 404 и 405 самого mux отдаются тем же JSON-конвертом, что и ошибки обработчиков
+	var api http.Handler = middleware.MuxErrorsMiddleware(mux)
+	if cfg.API.ValidateRequests || cfg.API.ValidateResponses {
+		validator, err := openapi.NewValidator()
+		if err != nil {
+			fatal("failed to load OpenAPI document", err)
+		}
+		api = middleware.OpenAPIValidationMiddleware(validator, cfg.API.ValidateRequests, cfg.API.ValidateResponses, api)
+	}
+	tokenAudit := audit.NewThrottle(auditLog, cfg.Audit.TokenInvalidInterval, cfg.Audit.QueueSize)
+	protectedMux := middleware.JWTAuthMiddleware(tokenAudit, middleware.TimeoutMiddleware(reloader, routePattern, api))
+
+	// Ограничитель срабатывает раньше всех остальных обработчиков, чтобы отбрасывать лишнее дёшево
+	concurrencyLimiter := limiter.New(limiterConfig(cfg.Limiter))
+	priorities := middleware.RoutePriorities{
+		Default: limiter.PriorityNormal,
+		Routes: map[string]limiter.Priority{
+			"/users/login":                   limiter.PriorityHigh,
+			"/users/create":                  limiter.PriorityHigh,
+			"/dialog/create":                 limiter.PriorityHigh,
+			"/dialog/send":                   limiter.PriorityHigh,
+			"/dialog/messages":               limiter.PriorityNormal,
+			"/dialog/user":                   limiter.PriorityNormal,
+			"/users/get":                     limiter.PriorityNormal,
+			"POST /v1/dialogs":               limiter.PriorityHigh,
+			"POST /v1/dialogs/{id}/messages": limiter.PriorityHigh,
+			"/v1/dialogs":                    limiter.PriorityNormal,
+			"/v1/dialogs/{id}/messages":      limiter.PriorityNormal,
+			"/v1/users":                      limiter.PriorityNormal,
+			"/v1/users/{id}":                 limiter.PriorityNormal,
+			"/notifications/longpoll":        limiter.PriorityLow,
+		},
+	}
+	// Пробы оркестратора обслуживаются в обход JWT и ограничителя нагрузки
+	checker := health.NewChecker(drainer, cfg.Health.Timeout)
+	checker.Add("redis", cfg.Health.IsCritical("redis"), health.RedisProbe(rdb))
+	checker.Add("dialogs", cfg.Health.IsCritical("dialogs"), health.GRPCProbe(dialogsConn))
+	checker.Add("users", cfg.Health.IsCritical("users"), health.GRPCProbe(usersConn))
+	if cfg.Health.CheckNotifications {
+		checker.Add("notifications", cfg.Health.IsCritical("notifications"),
+			health.HTTPProbe(http.DefaultClient, notificationHandler.BaseURL))
+	}
+
+	reloader.dialogs = dialogsConn
+	reloader.users = usersConn
+	reloader.notifications = notificationHandler
+	reloader.limiter = concurrencyLimiter
+	reloader.audit = auditLog
+
+	checker.RegisterHandlers(rootMux)
+	if cfg.API.Docs {
+		openapi.RegisterHandlers(rootMux)
+	}
+	rootMux.Handle("/", middleware.ConcurrencyLimitMiddleware(concurrencyLimiter, priorities, routePattern, protectedMux))
+	metrics.RegisterGaugeFunc("longpoll_connections", "Open long-poll connections.",
+		func() float64 { return float64(notificationHandler.ActiveLongPolls()) })
+	metrics.RegisterGaugeFunc("limiter_limit", "Current adaptive concurrency limit.",
+		func() float64 { return float64(concurrencyLimiter.Limit()) })
+	metrics.RegisterGaugeFunc("limiter_inflight", "Requests admitted by the concurrency limiter.",
+		func() float64 { return float64(concurrencyLimiter.InFlight()) })
+
+	// Клиенты постоянно опрашивают списки: ETag позволяет отвечать 304 вместо повторной выдачи тела
+	cachePolicies := middleware.CachePolicies{
+		Default: "private, no-cache",
+		Routes: map[string]string{
+			"/v1/users/{id}":          "private, max-age=60",
+			"/openapi.json":           "public, max-age=300",
+			"/docs":                   "public, max-age=300",
+			"/notifications/longpoll": "no-store",
+		},
+	}
 
 	// Запуск HTTP-сервера
-	srv := &http.Server{
-		Addr:         ":8080",
-		Handler:      protectedMux,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 35 * time.Second,
-		IdleTimeout:  60 * time.Second,
+	// Сжатие снаружи ETag: ETag считается по исходному телу, а сжатое получает суффикс кодировки
+	var handler http.Handler = middleware.ConditionalGETMiddleware(cachePolicies, routePattern, rootMux)
+	if cfg.Compression.Enabled {
+		handler = middleware.CompressionMiddleware(compressionConfig(cfg.Compression), routePattern, handler)
+	}
+	handler = middleware.MetricsMiddleware(routePattern, handler)
+	accessLog, closeAccessLog, err := accessLogOutput(cfg.AccessLog.Output)
+	if err != nil {
+		fatal("failed to open access log", err)
+	}
+	if accessLog != nil {
+		handler = middleware.AccessLogMiddleware(accessLog, cfg.AccessLog.Format, reloader, routePattern, handler)
+	}
+	handler = middleware.RequestIDMiddleware(routePattern, middleware.TracingMiddleware(routePattern, handler))
+	srv, serve, err := newHTTPServer(ctx, cfg.HTTP, handler)
+	if err != nil {
+		fatal("failed to set up HTTP server", err)
 	}
 
-	log.Println("HTTP сервер запущен")
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("ошибка запуска HTTP-сервера: %v", err)
+	serveErr := make(chan error, 3)
+	go func() {
+		slog.Info("HTTP server started", "addr", cfg.HTTP.Addr, "tls", cfg.HTTP.TLS.Enabled)
+		serveErr <- serve()
+	}()
+
+	// Служебный listener: pprof, метрики и диагностика не должны попадать на публичный адрес
+	adminHandler := admin.NewHandler(version)
+	adminHandler.Config = func() ([]byte, error) { return reloader.config().Redacted() }
+	adminHandler.LongPolls = notificationHandler.ActiveLongPolls
+	adminHandler.Gauges["limiter_limit"] = func() int64 { return int64(concurrencyLimiter.Limit()) }
+	adminHandler.Gauges["limiter_inflight"] = func() int64 { return int64(concurrencyLimiter.InFlight()) }
+	adminHandler.Upstreams["dialogs"] = dialogsConn
+	adminHandler.Upstreams["users"] = usersConn
+	adminHandler.Endpoints["dialogs"] = func() any { return dialogsBalancing.Stats() }
+	adminHandler.Endpoints["users"] = func() any { return usersBalancing.Stats() }
+	adminHandler.Publish()
+
+	var auditQuery http.HandlerFunc
+	if auditLog != nil {
+		auditQuery = reloader.adminOnly(audit.QueryHandler(auditLog))
+	}
+	adminSrv := newAdminServer(cfg.Admin, adminHandler, reloader.ReloadHandler(), auditQuery)
+	if adminSrv != nil {
+		go func() {
+			slog.Info("admin listener started", "addr", adminSrv.Addr)
+			serveErr <- adminSrv.ListenAndServe()
+		}()
+	}
+
+	redirectSrv := newRedirectServer(cfg.HTTP)
+	if redirectSrv != nil {
+		go func() {
+			slog.Info("HTTP to HTTPS redirect started", "addr", redirectSrv.Addr)
+			serveErr <- redirectSrv.ListenAndServe()
+		}()
 	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for running := true; running; {
+		select {
+		case err := <-serveErr:
+			if !errors.Is(err, http.ErrServerClosed) {
+				fatal("HTTP server failed", err)
+			}
+			running = false
+		case <-hup:
+			err := reloader.reload()
+			auditLog.Record(ctx, reloadEvent("signal", err))
+			if err != nil {
+				slog.Error("configuration reload rejected", "error", err)
+			}
+		case <-ctx.Done():
+			running = false
+		}
+	}
+	stop()
+
+	// Порядок остановки: readiness проваливается и long-poll запросы просят клиентов переподключиться,
+	// затем listener перестаёт принимать соединения, а запросы в обработке дорабатывают до дедлайна.
+	slog.Info("shutdown signal received, stopping gateway")
+	drainer.Drain()
+	time.Sleep(cfg.Shutdown.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Shutdown.Timeout)
+	defer cancel()
+	if redirectSrv != nil {
+		_ = redirectSrv.Shutdown(shutdownCtx)
+	}
+	if adminSrv != nil {
+		_ = adminSrv.Shutdown(shutdownCtx)
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("not all requests finished before the shutdown deadline", "error", err)
+		_ = srv.Close()
+	}
+
+	if err := dialogsConn.Close(); err != nil {
+		slog.Warn("failed to close dialogs gRPC", "error", err)
+	}
+	if err := usersConn.Close(); err != nil {
+		slog.Warn("failed to close users gRPC", "error", err)
+	}
+	if err := closeAccessLog(); err != nil {
+		slog.Warn("failed to close access log", "error", err)
+	}
+	tokenAudit.Close()
+	if err := closeAudit(); err != nil {
+		slog.Warn("failed to close audit log", "error", err)
+	}
+	if err := rdb.Close(); err != nil {
+		slog.Warn("failed to close Redis", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Warn("failed to flush remaining spans", "error", err)
+	}
+	slog.Info("HTTP server stopped")
+}
+
+func compressionConfig(c config.CompressionConfig) middleware.Compression {
+	exclude := make(map[string]bool, len(c.ExcludeRoutes))
+	for _, route := range c.ExcludeRoutes {
+		exclude[route] = true
+	}
+	return middleware.Compression{
+		Encodings:    c.Encodings,
+		MinSize:      c.MinSize,
+		ContentTypes: c.ContentTypes,
+		Exclude:      exclude,
+	}
+}
+
+func limiterConfig(c config.LimiterConfig) limiter.Config {
+	lc := limiter.DefaultConfig()
+	lc.InitialLimit = c.InitialLimit
+	lc.MinLimit = c.MinLimit
+	lc.MaxLimit = c.MaxLimit
+	lc.TargetLatency = c.TargetLatency
+	lc.BackoffRatio = c.BackoffRatio
+	return lc
 }
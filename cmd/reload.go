@@ -0,0 +1,183 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"messenger_frontend/internal/apierror"
+	"messenger_frontend/internal/audit"
+	"messenger_frontend/internal/config"
+	"messenger_frontend/internal/handlers"
+	"messenger_frontend/internal/jwt"
+	"messenger_frontend/internal/limiter"
+	"messenger_frontend/internal/middleware"
+	"messenger_frontend/internal/upstream"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// reloader перечитывает конфигурацию по SIGHUP или POST /admin/reload и применяет
+// изменения без перезапуска. Некорректная конфигурация отклоняется, старая остаётся в силе.
+type reloader struct {
+	name string
+	args []string
+
+	mu  sync.Mutex
+	cfg atomic.Pointer[config.Config]
+
+	dialogs       *upstream.Conn
+	users         *upstream.Conn
+	notifications *handlers.NotificationHandler
+	limiter       *limiter.Limiter
+	logLevel      *slog.LevelVar
+	audit         *audit.Logger
+}
+
+func newReloader(name string, args []string, cfg config.Config) *reloader {
+	r := &reloader{name: name, args: args}
+	r.cfg.Store(&cfg)
+	return r
+}
+
+// Причины отказа в перезагрузке. Подробности (пути, адреса) остаются в логе,
+// а в ответ и в журнал аудита попадает только причина.
+var (
+	errConfigRejected = errors.New("config rejected")
+	errRedialFailed   = errors.New("redial failed")
+)
+
+func (r *reloader) config() config.Config {
+	return *r.cfg.Load()
+}
+
+func (r *reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := config.Load(r.name, r.args)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errConfigRejected, err)
+	}
+	prev := r.config()
+
+	// Сначала переподключаемся: если новый адрес не разбирается, конфигурация не применяется целиком
+	if err := r.dialogs.Redial(next.Upstreams.Dialogs.Target()); err != nil {
+		return fmt.Errorf("%w: dialogs: %w", errRedialFailed, err)
+	}
+	if err := r.users.Redial(next.Upstreams.Users.Target()); err != nil {
+		_ = r.dialogs.Redial(prev.Upstreams.Dialogs.Target())
+		return fmt.Errorf("%w: users: %w", errRedialFailed, err)
+	}
+	r.notifications.SetBaseURL(next.Upstreams.Notifications)
+	jwt.SetSecret([]byte(next.JWT.Secret.Value()))
+	r.limiter.Reconfigure(limiterConfig(next.Limiter))
+	if r.logLevel != nil {
+		_ = r.logLevel.UnmarshalText([]byte(next.Log.Level))
+	}
+	r.cfg.Store(&next)
+
+	for name, changed := range map[string]bool{
+		"http":              !reflect.DeepEqual(prev.HTTP, next.HTTP),
+		"redis":             !reflect.DeepEqual(prev.Redis, next.Redis),
+		"health":            !reflect.DeepEqual(prev.Health, next.Health),
+		"shutdown":          !reflect.DeepEqual(prev.Shutdown, next.Shutdown),
+		"admin.addr":        prev.Admin.Addr != next.Admin.Addr,
+		"tracing":           !reflect.DeepEqual(prev.Tracing, next.Tracing),
+		"audit":             !reflect.DeepEqual(prev.Audit, next.Audit),
+		"api":               !reflect.DeepEqual(prev.API, next.API),
+		"compression":       !reflect.DeepEqual(prev.Compression, next.Compression),
+		"access_log.output": prev.AccessLog.Output != next.AccessLog.Output || prev.AccessLog.Format != next.AccessLog.Format,
+		"upstreams.dialogs": dialSettingsChanged(prev.Upstreams.Dialogs, next.Upstreams.Dialogs),
+		"upstreams.users":   dialSettingsChanged(prev.Upstreams.Users, next.Upstreams.Users),
+	} {
+		if changed {
+			slog.Warn("reload: change takes effect after restart", "section", name)
+		}
+	}
+	slog.Info("configuration reloaded")
+	return nil
+}
+
+// For реализует middleware.Timeouts поверх текущей конфигурации.
+func (r *reloader) For(path string) time.Duration {
+	cfg := r.cfg.Load()
+	if d, ok := cfg.Timeouts.Routes[path]; ok {
+		return d
+	}
+	return cfg.Timeouts.Default
+}
+
+// SampleRate и SlowThreshold реализуют middleware.AccessLogSampling поверх текущей конфигурации.
+func (r *reloader) SampleRate(route string) float64 {
+	cfg := r.cfg.Load()
+	return middleware.AccessLogRules{Default: cfg.AccessLog.SampleRate, Routes: cfg.AccessLog.Routes}.SampleRate(route)
+}
+
+func (r *reloader) SlowThreshold() time.Duration {
+	return r.cfg.Load().AccessLog.SlowThreshold
+}
+
+// ReloadHandler обслуживает POST /admin/reload.
+func (r *reloader) ReloadHandler() http.HandlerFunc {
+	return r.adminOnly(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			apierror.Write(w, req, apierror.MethodNotAllowed())
+			return
+		}
+		err := r.reload()
+		r.audit.RecordRequest(req, reloadEvent("http", err))
+		if err != nil {
+			slog.ErrorContext(req.Context(), "reload failed", "error", err)
+			apierror.Write(w, req, apierror.New(http.StatusUnprocessableEntity, apierror.CodeReloadRejected))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// adminOnly требует заголовок X-Admin-Token. Без настроенного admin.token эндпоинт недоступен,
+// неверный токен попадает в журнал аудита.
+func (r *reloader) adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		token := r.config().Admin.Token.Value()
+		if token == "" {
+			apierror.Write(w, req, apierror.NotFound(apierror.CodeNotFound))
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(req.Header.Get("X-Admin-Token")), []byte(token)) != 1 {
+			r.audit.RecordRequest(req, audit.Event{Type: audit.AdminDenied, Details: map[string]string{"path": req.URL.Path}})
+			apierror.Write(w, req, apierror.Unauthorized(apierror.CodeUnauthenticated))
+			return
+		}
+		next(w, req)
+	}
+}
+
+// reloadEvent описывает перезагрузку конфигурации для журнала аудита; source — http или signal.
+func reloadEvent(source string, err error) audit.Event {
+	e := audit.Event{Type: audit.AdminReload, Details: map[string]string{"source": source, "result": "applied"}}
+	if err != nil {
+		e.Details["result"] = "rejected"
+		e.Reason = "reload failed"
+		for _, reason := range []error{errConfigRejected, errRedialFailed} {
+			if errors.Is(err, reason) {
+				e.Reason = reason.Error()
+			}
+		}
+	}
+	return e
+}
+
+// dialSettingsChanged сравнивает всё, кроме адресов реплик: адреса применяются через Redial,
+// а балансировка, keepalive и TLS задаются при первом подключении.
+func dialSettingsChanged(prev, next config.GRPCUpstreamConfig) bool {
+	for _, u := range []*config.GRPCUpstreamConfig{&prev, &next} {
+		u.Addr, u.Discovery, u.Endpoints, u.File = "", "", nil, ""
+	}
+	return !reflect.DeepEqual(prev, next)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"messenger_frontend/internal/audit"
+	"messenger_frontend/internal/config"
+)
+
+type memorySink struct{ events []audit.Event }
+
+func (s *memorySink) Append(_ context.Context, e audit.Event) (audit.Event, error) {
+	s.events = append(s.events, e)
+	return e, nil
+}
+
+func (s *memorySink) Query(context.Context, audit.Filter) ([]audit.Event, error) {
+	return s.events, nil
+}
+
+func TestReloadHandler_HidesRejectionDetails(t *testing.T) {
+	cfg := config.Default()
+	cfg.Admin.Token = "admin-token"
+	sink := &memorySink{}
+	r := newReloader("gateway", []string{"-config", "/etc/secret-dir/gateway.yaml"}, cfg)
+	r.audit = audit.New(sink)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+	req.Header.Set("X-Admin-Token", "admin-token")
+	w := httptest.NewRecorder()
+	r.ReloadHandler().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"code":"reload_rejected"`)
+	assert.NotContains(t, w.Body.String(), "secret-dir")
+
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, "config rejected", sink.events[0].Reason)
+
+	// Неверный токен и неподдерживаемый метод тоже отвечают конвертом ошибки
+	req = httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+	w = httptest.NewRecorder()
+	r.ReloadHandler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"unauthenticated"`)
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/reload", nil)
+	req.Header.Set("X-Admin-Token", "admin-token")
+	w = httptest.NewRecorder()
+	r.ReloadHandler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, http.MethodPost, w.Header().Get("Allow"))
+	assert.Contains(t, w.Body.String(), `"code":"method_not_allowed"`)
+}
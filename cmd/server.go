@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"io"
+	"messenger_frontend/internal/admin"
+	"messenger_frontend/internal/audit"
+	"messenger_frontend/internal/config"
+	"messenger_frontend/internal/tlsutil"
+	"messenger_frontend/internal/upstream"
+	"net/http"
+	"os"
+	"time"
+)
+
+// newHTTPServer собирает публичный сервер. С TLS сертификат перечитывается с диска при изменении,
+// а HTTP/2 включается через ALPN; без TLS по желанию доступен h2c для внутреннего трафика.
+// Возвращённая функция запускает прослушивание.
+func newHTTPServer(ctx context.Context, cfg config.HTTPConfig, handler http.Handler) (*http.Server, func() error, error) {
+	srv := &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+
+	if !cfg.TLS.Enabled {
+		if cfg.H2C {
+			srv.Handler = h2c.NewHandler(handler, &http2.Server{IdleTimeout: cfg.IdleTimeout})
+		}
+		return srv, srv.ListenAndServe, nil
+	}
+
+	certs, err := tlsutil.NewCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	go certs.Watch(ctx, cfg.TLS.ReloadInterval)
+
+	// Значения уже проверены config.Validate
+	minVersion, _ := tlsutil.ParseVersion(cfg.TLS.MinVersion)
+	cipherSuites, _ := tlsutil.ParseCipherSuites(cfg.TLS.CipherSuites)
+	srv.TLSConfig = &tls.Config{
+		MinVersion:     minVersion,
+		CipherSuites:   cipherSuites,
+		GetCertificate: certs.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	return srv, func() error { return srv.ListenAndServeTLS("", "") }, nil
+}
+
+// newRedirectServer возвращает listener, перенаправляющий HTTP на HTTPS, или nil, если он не настроен.
+func newRedirectServer(cfg config.HTTPConfig) *http.Server {
+	if cfg.RedirectAddr == "" {
+		return nil
+	}
+	return &http.Server{
+		Addr:              cfg.RedirectAddr,
+		Handler:           tlsutil.RedirectHandler(cfg.Addr),
+		ReadHeaderTimeout: 5 * time.Second,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// newAdminServer возвращает служебный listener или nil, если admin.addr пуст.
+// auditQuery равен nil, когда журнал аудита отключён.
+func newAdminServer(cfg config.AdminConfig, h *admin.Handler, reload, auditQuery http.HandlerFunc) *http.Server {
+	if cfg.Addr == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	h.RegisterHandlers(mux)
+	mux.HandleFunc("/admin/reload", reload)
+	if auditQuery != nil {
+		mux.HandleFunc("/admin/audit", auditQuery)
+	}
+	// WriteTimeout не задан: pprof profile и trace пишут ответ дольше обычного запроса
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+// dialUpstream подключается к gRPC-сервису: реплики находятся по cfg.Discovery и балансируются
+// на стороне шлюза, неисправные временно исключаются. Возвращает и Balancing для статистики.
+func dialUpstream(ctx context.Context, name string, cfg config.GRPCUpstreamConfig) (*upstream.Conn, *upstream.Balancing, error) {
+	creds, err := transportCredentials(ctx, cfg.TLS)
+	if err != nil {
+		return nil, nil, err
+	}
+	balancing := upstream.NewBalancing(upstream.BalancingOptions{
+		Policy:      cfg.Balancer,
+		HealthCheck: cfg.HealthCheck,
+		Refresh:     cfg.RefreshInterval,
+		Outlier: upstream.OutlierConfig{
+			ConsecutiveFailures: cfg.Outlier.ConsecutiveFailures,
+			BaseEjectionTime:    cfg.Outlier.BaseEjectionTime,
+			MaxEjectionPercent:  cfg.Outlier.MaxEjectionPercent,
+		},
+	})
+	opts := append(balancing.DialOptions(),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUnaryInterceptor(upstream.UnaryClientInterceptor(name)),
+	)
+	if cfg.Keepalive.Time > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.Keepalive.Time,
+			Timeout:             cfg.Keepalive.Timeout,
+			PermitWithoutStream: cfg.Keepalive.PermitWithoutStream,
+		}))
+	}
+	conn, err := upstream.Dial(cfg.Target(), opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, balancing, nil
+}
+
+// transportCredentials возвращает TLS (и mTLS, если задан клиентский сертификат) или
+// нешифрованное подключение. Сертификаты и CA перечитываются при изменении.
+func transportCredentials(ctx context.Context, cfg config.ClientTLSConfig) (credentials.TransportCredentials, error) {
+	if !cfg.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+	tlsConfig, err := tlsutil.NewClientConfig(ctx, tlsutil.ClientOptions{
+		CAFile:         cfg.CAFile,
+		CertFile:       cfg.CertFile,
+		KeyFile:        cfg.KeyFile,
+		ServerName:     cfg.ServerName,
+		ReloadInterval: cfg.ReloadInterval,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// newAuditLog открывает журнал аудита; при sink none возвращает nil, и события не пишутся.
+func newAuditLog(cfg config.AuditConfig, rdb redis.UniversalClient) (*audit.Logger, func() error, error) {
+	switch cfg.Sink {
+	case config.AuditFile:
+		sink, err := audit.NewFileSink(cfg.File)
+		if err != nil {
+			return nil, nil, err
+		}
+		return audit.New(sink), sink.Close, nil
+	case config.AuditRedis:
+		return audit.New(audit.NewRedisSink(rdb, cfg.Stream, cfg.MaxLen)), func() error { return nil }, nil
+	}
+	return nil, func() error { return nil }, nil
+}
+
+// accessLogOutput открывает вывод журнала доступа; nil означает, что журнал отключён.
+func accessLogOutput(output string) (io.Writer, func() error, error) {
+	noClose := func() error { return nil }
+	switch output {
+	case "none":
+		return nil, noClose, nil
+	case "stdout":
+		return os.Stdout, noClose, nil
+	case "stderr":
+		return os.Stderr, noClose, nil
+	}
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f.Close, nil
+}
@@ -0,0 +1,138 @@
+package admin
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"messenger_frontend/internal/health"
+	"messenger_frontend/internal/metrics"
+)
+
+// Handler обслуживает служебный listener: pprof, метрики Prometheus и expvar, конфигурацию и состояние процесса.
+// Публичный mux с JWT для этого не подходит, поэтому эндпоинты вынесены на отдельный адрес.
+type Handler struct {
+	version string
+	started time.Time
+
+	// Config возвращает действующую конфигурацию с замаскированными секретами.
+	Config func() ([]byte, error)
+	// LongPolls возвращает число открытых long-poll соединений.
+	LongPolls func() int64
+	// Gauges — дополнительные числовые показатели, например лимит и загрузка ограничителя.
+	Gauges map[string]func() int64
+	// Upstreams — gRPC-подключения, состояние которых попадает в /admin/runtime.
+	Upstreams map[string]health.StateConn
+	// Endpoints — статистика по репликам каждого upstream.
+	Endpoints map[string]func() any
+}
+
+func NewHandler(version string) *Handler {
+	return &Handler{
+		version:   version,
+		started:   time.Now(),
+		Gauges:    map[string]func() int64{},
+		Upstreams: map[string]health.StateConn{},
+		Endpoints: map[string]func() any{},
+	}
+}
+
+func (h *Handler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/admin/config", h.ConfigHandler())
+	mux.HandleFunc("/admin/runtime", h.RuntimeHandler())
+}
+
+// Publish добавляет снимок состояния в /debug/vars под именем "gateway".
+// expvar не допускает повторной регистрации, поэтому вызывается один раз за процесс.
+func (h *Handler) Publish() {
+	expvar.Publish("gateway", expvar.Func(func() any { return h.Snapshot() }))
+}
+
+type Runtime struct {
+	Version       string            `json:"version"`
+	GoVersion     string            `json:"go_version"`
+	Revision      string            `json:"revision,omitempty"`
+	UptimeSeconds int64             `json:"uptime_seconds"`
+	Goroutines    int               `json:"goroutines"`
+	LongPolls     int64             `json:"longpolls"`
+	Gauges        map[string]int64  `json:"gauges,omitempty"`
+	Upstreams     map[string]string `json:"upstreams"`
+	Endpoints     map[string]any    `json:"endpoints,omitempty"`
+}
+
+func (h *Handler) Snapshot() Runtime {
+	rt := Runtime{
+		Version:       h.version,
+		GoVersion:     runtime.Version(),
+		Revision:      revision(),
+		UptimeSeconds: int64(time.Since(h.started).Seconds()),
+		Goroutines:    runtime.NumGoroutine(),
+		Gauges:        make(map[string]int64, len(h.Gauges)),
+		Upstreams:     make(map[string]string, len(h.Upstreams)),
+		Endpoints:     make(map[string]any, len(h.Endpoints)),
+	}
+	if h.LongPolls != nil {
+		rt.LongPolls = h.LongPolls()
+	}
+	for name, gauge := range h.Gauges {
+		rt.Gauges[name] = gauge()
+	}
+	for name, conn := range h.Upstreams {
+		rt.Upstreams[name] = conn.GetState().String()
+	}
+	for name, endpoints := range h.Endpoints {
+		rt.Endpoints[name] = endpoints()
+	}
+	return rt
+}
+
+func (h *Handler) RuntimeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(h.Snapshot())
+	}
+}
+
+func (h *Handler) ConfigHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.Config == nil {
+			http.NotFound(w, r)
+			return
+		}
+		out, err := h.Config()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(out)
+	}
+}
+
+// revision берёт коммит из информации о сборке, если бинарник собран из git-репозитория.
+func revision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	settings := make(map[string]string, len(info.Settings))
+	for _, s := range info.Settings {
+		settings[s.Key] = s.Value
+	}
+	rev := settings["vcs.revision"]
+	if rev != "" && settings["vcs.modified"] == "true" {
+		rev += "-dirty"
+	}
+	return rev
+}
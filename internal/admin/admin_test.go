@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/connectivity"
+)
+
+type stateConn connectivity.State
+
+func (s stateConn) GetState() connectivity.State { return connectivity.State(s) }
+func (s stateConn) Connect()                     {}
+func (s stateConn) WaitForStateChange(context.Context, connectivity.State) bool {
+	return false
+}
+
+func TestHandler_Runtime(t *testing.T) {
+	h := NewHandler("1.2.3")
+	h.LongPolls = func() int64 { return 7 }
+	h.Gauges["limiter_limit"] = func() int64 { return 100 }
+	h.Upstreams["dialogs"] = stateConn(connectivity.Ready)
+	h.Upstreams["users"] = stateConn(connectivity.TransientFailure)
+
+	mux := http.NewServeMux()
+	h.RegisterHandlers(mux)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/runtime", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var rt Runtime
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rt))
+	assert.Equal(t, "1.2.3", rt.Version)
+	assert.Equal(t, int64(7), rt.LongPolls)
+	assert.Positive(t, rt.Goroutines)
+	assert.Equal(t, int64(100), rt.Gauges["limiter_limit"])
+	assert.Equal(t, map[string]string{"dialogs": "READY", "users": "TRANSIENT_FAILURE"}, rt.Upstreams)
+}
+
+func TestHandler_ConfigAndPprof(t *testing.T) {
+	h := NewHandler("dev")
+	h.Config = func() ([]byte, error) { return []byte("jwt:\n  secret: '[REDACTED]'\n"), nil }
+
+	mux := http.NewServeMux()
+	h.RegisterHandlers(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "[REDACTED]")
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "goroutine")
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "memstats")
+}
@@ -0,0 +1,153 @@
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"messenger_frontend/internal/i18n"
+	"messenger_frontend/internal/logging"
+)
+
+// StatusClientClosedRequest — нестандартный статус nginx для запроса, который клиент отменил сам.
+const StatusClientClosedRequest = 499
+
+// FieldViolation указывает на конкретное поле запроса, не прошедшее проверку.
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// Error — ошибка API. Клиент получает её конвертом {code, message, details, request_id}.
+// Текст сообщения всегда берётся из каталога i18n при записи ответа: перевод Key, а без него —
+// перевод Code. Подробности, относящиеся к полям запроса, передаются только в Details.
+type Error struct {
+	Status  int
+	Code    string
+	Key     string
+	Details []FieldViolation
+}
+
+func (e *Error) Error() string {
+	return e.Code
+}
+
+func New(status int, code string) *Error {
+	return &Error{Status: status, Code: code}
+}
+
+func BadRequest(code string) *Error {
+	return New(http.StatusBadRequest, code)
+}
+
+func Unauthorized(code string) *Error {
+	return New(http.StatusUnauthorized, code)
+}
+
+func NotFound(code string) *Error {
+	return New(http.StatusNotFound, code)
+}
+
+func MethodNotAllowed() *Error {
+	return New(http.StatusMethodNotAllowed, CodeMethodNotAllowed)
+}
+
+// Internal описывает внутреннюю ошибку с кодом internal и сообщением по ключу key.
+func Internal(key string) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Key: key}
+}
+
+// grpcStatuses сопоставляет коды gRPC с HTTP-статусами и кодами ошибок API.
+var grpcStatuses = map[codes.Code]struct {
+	status int
+	code   string
+}{
+	codes.Canceled:           {StatusClientClosedRequest, CodeCanceled},
+	codes.Unknown:            {http.StatusInternalServerError, CodeInternal},
+	codes.InvalidArgument:    {http.StatusBadRequest, CodeInvalidArgument},
+	codes.DeadlineExceeded:   {http.StatusGatewayTimeout, CodeDeadlineExceeded},
+	codes.NotFound:           {http.StatusNotFound, CodeNotFound},
+	codes.AlreadyExists:      {http.StatusConflict, CodeAlreadyExists},
+	codes.PermissionDenied:   {http.StatusForbidden, CodePermissionDenied},
+	codes.ResourceExhausted:  {http.StatusTooManyRequests, CodeResourceExhausted},
+	codes.FailedPrecondition: {http.StatusBadRequest, CodeFailedPrecondition},
+	codes.Aborted:            {http.StatusConflict, CodeAborted},
+	codes.OutOfRange:         {http.StatusBadRequest, CodeOutOfRange},
+	codes.Unimplemented:      {http.StatusNotImplemented, CodeUnimplemented},
+	codes.Internal:           {http.StatusInternalServerError, CodeInternal},
+	codes.Unavailable:        {http.StatusServiceUnavailable, CodeUnavailable},
+	codes.DataLoss:           {http.StatusInternalServerError, CodeInternal},
+	codes.Unauthenticated:    {http.StatusUnauthorized, CodeUnauthenticated},
+}
+
+// FromGRPC переводит ошибку upstream-сервиса в ошибку API. Текст статуса upstream клиенту
+// не передаётся: это внутренние подробности на языке сервиса. Ошибки 4xx получают перевод
+// своего кода, 5xx — сообщение по ключу key. Нарушения полей из errdetails.BadRequest
+// передаются в Details.
+func FromGRPC(err error, key string) *Error {
+	st := status.Convert(err)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		st = status.New(codes.DeadlineExceeded, st.Message())
+	case errors.Is(err, context.Canceled):
+		st = status.New(codes.Canceled, st.Message())
+	}
+
+	mapped, ok := grpcStatuses[st.Code()]
+	if !ok {
+		mapped = grpcStatuses[codes.Unknown]
+	}
+	e := New(mapped.status, mapped.code)
+	if mapped.status >= http.StatusInternalServerError {
+		e.Key = key
+	}
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				e.Details = append(e.Details, FieldViolation{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		}
+	}
+	return e
+}
+
+type envelope struct {
+	Code      string           `json:"code"`
+	Message   string           `json:"message"`
+	Details   []FieldViolation `json:"details,omitempty"`
+	RequestID string           `json:"request_id,omitempty"`
+}
+
+// Write отвечает ошибкой в виде JSON-конверта на языке, выбранном i18n.Locale. Любая ошибка,
+// кроме *Error, отдаётся как 500 без подробностей.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = New(http.StatusInternalServerError, CodeInternal)
+	}
+	locale := i18n.Locale(r)
+	key := e.Key
+	if key == "" {
+		key = e.Code
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Content-Language", locale)
+	// Язык зависит и от cookie lang, поэтому кэш должен различать ответы по обоим заголовкам
+	h.Add("Vary", "Accept-Language")
+	h.Add("Vary", "Cookie")
+	w.WriteHeader(e.Status)
+	_ = json.NewEncoder(w).Encode(envelope{
+		Code:      e.Code,
+		Message:   i18n.T(locale, key),
+		Details:   e.Details,
+		RequestID: logging.RequestID(r.Context()),
+	})
+}
@@ -0,0 +1,147 @@
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"messenger_frontend/internal/logging"
+)
+
+func TestFromGRPC_StatusMapping(t *testing.T) {
+	tests := []struct {
+		code   codes.Code
+		status int
+		apiErr string
+	}{
+		{codes.NotFound, http.StatusNotFound, CodeNotFound},
+		{codes.AlreadyExists, http.StatusConflict, CodeAlreadyExists},
+		{codes.InvalidArgument, http.StatusBadRequest, CodeInvalidArgument},
+		{codes.Unauthenticated, http.StatusUnauthorized, CodeUnauthenticated},
+		{codes.Unavailable, http.StatusServiceUnavailable, CodeUnavailable},
+		{codes.DeadlineExceeded, http.StatusGatewayTimeout, CodeDeadlineExceeded},
+		{codes.Internal, http.StatusInternalServerError, CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			e := FromGRPC(status.Error(tt.code, "upstream text"), MsgGetUserFailed)
+			assert.Equal(t, tt.status, e.Status)
+			assert.Equal(t, tt.apiErr, e.Code)
+		})
+	}
+}
+
+func TestFromGRPC_HidesServerErrorText(t *testing.T) {
+	e := FromGRPC(status.Error(codes.Internal, "pq: connection refused"), MsgCreateUserFailed)
+	assert.Equal(t, MsgCreateUserFailed, e.Key)
+
+	// Текст 4xx тоже не передаётся: клиент получает перевод кода
+	e = FromGRPC(status.Error(codes.AlreadyExists, "логин уже занят"), MsgCreateUserFailed)
+	assert.Empty(t, e.Key)
+	r := httptest.NewRequest(http.MethodPost, "/users/create", nil)
+	r.Header.Set("Accept-Language", "en")
+	w := httptest.NewRecorder()
+	Write(w, r, e)
+	assert.JSONEq(t, `{"code":"already_exists","message":"Already exists"}`, w.Body.String())
+
+	// Ошибки без статуса gRPC считаются внутренними
+	e = FromGRPC(errors.New("boom"), MsgCreateUserFailed)
+	assert.Equal(t, http.StatusInternalServerError, e.Status)
+	assert.Equal(t, MsgCreateUserFailed, e.Key)
+
+	e = FromGRPC(fmt.Errorf("call: %w", context.DeadlineExceeded), MsgCreateUserFailed)
+	assert.Equal(t, http.StatusGatewayTimeout, e.Status)
+}
+
+func TestFromGRPC_FieldViolations(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "invalid user").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "email", Description: "invalid format"},
+		},
+	})
+	require.NoError(t, err)
+
+	e := FromGRPC(st.Err(), MsgGetUserFailed)
+	assert.Equal(t, http.StatusBadRequest, e.Status)
+	assert.Equal(t, []FieldViolation{{Field: "email", Description: "invalid format"}}, e.Details)
+}
+
+func TestWrite_Envelope(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(logging.WithRequestID(r.Context(), "req-1"))
+	w := httptest.NewRecorder()
+
+	Write(w, r, &Error{Status: http.StatusBadRequest, Code: CodeInvalidArgument,
+		Details: []FieldViolation{{Field: "text", Description: "required"}}})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, map[string]any{
+		"code":       "invalid_argument",
+		"message":    "Некорректный запрос",
+		"details":    []any{map[string]any{"field": "text", "description": "required"}},
+		"request_id": "req-1",
+	}, body)
+}
+
+func TestWrite_UnknownErrorIsInternal(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	Write(w, r, errors.New("secret detail"))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret detail")
+}
+
+func TestWrite_TranslatesByLocale(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(r *http.Request)
+		locale  string
+		message string
+	}{
+		{"default", func(*http.Request) {}, "ru", "Пользователь не найден"},
+		{"accept-language", func(r *http.Request) { r.Header.Set("Accept-Language", "en-US,ru;q=0.5") }, "en", "User not found"},
+		{"cookie wins", func(r *http.Request) {
+			r.Header.Set("Accept-Language", "en")
+			r.AddCookie(&http.Cookie{Name: "lang", Value: "ru"})
+		}, "ru", "Пользователь не найден"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/users/7", nil)
+			tt.prepare(r)
+			w := httptest.NewRecorder()
+
+			Write(w, r, NotFound(CodeUserNotFound))
+
+			assert.Equal(t, tt.locale, w.Header().Get("Content-Language"))
+			assert.Equal(t, []string{"Accept-Language", "Cookie"}, w.Header().Values("Vary"))
+			assert.JSONEq(t, `{"code":"user_not_found","message":"`+tt.message+`"}`, w.Body.String())
+		})
+	}
+}
+
+func TestWrite_InternalUsesMessageKey(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "en")
+	w := httptest.NewRecorder()
+
+	Write(w, r, FromGRPC(status.Error(codes.Unavailable, "connection refused"), MsgSendMessageFailed))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"code":"unavailable","message":"Failed to send message"}`, w.Body.String())
+}
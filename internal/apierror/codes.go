@@ -0,0 +1,52 @@
+package apierror
+
+// Машинно-читаемые коды ошибок. Общие коды совпадают с названиями кодов gRPC,
+// уточняющие описывают конкретную ошибку запроса. Для каждого кода в каталоге i18n
+// есть перевод на все поддерживаемые языки.
+const (
+	CodeInvalidArgument    = "invalid_argument"
+	CodeUnauthenticated    = "unauthenticated"
+	CodePermissionDenied   = "permission_denied"
+	CodeNotFound           = "not_found"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeAlreadyExists      = "already_exists"
+	CodeFailedPrecondition = "failed_precondition"
+	CodeAborted            = "aborted"
+	CodeOutOfRange         = "out_of_range"
+	CodeResourceExhausted  = "resource_exhausted"
+	CodeCanceled           = "canceled"
+	CodeUnimplemented      = "unimplemented"
+	CodeInternal           = "internal"
+	CodeBadGateway         = "bad_gateway"
+	CodeUnavailable        = "unavailable"
+	CodeDeadlineExceeded   = "deadline_exceeded"
+
+	CodeInvalidBody              = "invalid_body"
+	CodeTokenMissing             = "token_missing"
+	CodeTokenInvalid             = "token_invalid"
+	CodeInvalidCredentials       = "invalid_credentials"
+	CodeCredentialsRequired      = "credentials_required"
+	CodeUserIDInvalid            = "user_id_invalid"
+	CodeUserNotFound             = "user_not_found"
+	CodeSearchParamsRequired     = "search_params_required"
+	CodeDialogIDRequired         = "dialog_id_required"
+	CodeDialogIDInvalid          = "dialog_id_invalid"
+	CodeMessageFieldsRequired    = "message_fields_required"
+	CodePagingInvalid            = "paging_invalid"
+	CodeOverloaded               = "overloaded"
+	CodeNotificationsUnavailable = "notifications_unavailable"
+	CodeServerRestarting         = "server_restarting"
+	CodeReloadRejected           = "reload_rejected"
+)
+
+// Ключи сообщений для ошибок 5xx: код остаётся общим, а текст объясняет, какое действие не удалось.
+const (
+	MsgCreateDialogFailed = "create_dialog_failed"
+	MsgSendMessageFailed  = "send_message_failed"
+	MsgGetDialogsFailed   = "get_dialogs_failed"
+	MsgGetMessagesFailed  = "get_messages_failed"
+	MsgGetUserFailed      = "get_user_failed"
+	MsgLoginFailed        = "login_failed"
+	MsgCreateUserFailed   = "create_user_failed"
+	MsgTokenSaveFailed    = "token_save_failed"
+)
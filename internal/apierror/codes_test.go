@@ -0,0 +1,83 @@
+package apierror
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"messenger_frontend/internal/i18n"
+)
+
+// Коды собираются из исходника, чтобы новый код без перевода не прошёл тесты.
+func TestCodes_TranslatedForEveryLocale(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "codes.go", nil, 0)
+	require.NoError(t, err)
+
+	var keys []string
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				if !strings.HasPrefix(name.Name, "Code") && !strings.HasPrefix(name.Name, "Msg") {
+					continue
+				}
+				value, err := strconv.Unquote(vs.Values[i].(*ast.BasicLit).Value)
+				require.NoError(t, err)
+				keys = append(keys, value)
+			}
+		}
+	}
+	require.NotEmpty(t, keys)
+
+	for _, locale := range i18n.Supported {
+		for _, key := range keys {
+			assert.True(t, i18n.Has(locale, key), "no %q translation for %q", locale, key)
+		}
+	}
+}
+
+// Текст для клиента берётся только из каталога i18n: русская строка в коде пакетов, которые
+// пишут ответы, означает сообщение мимо перевода.
+func TestResponses_NoHardcodedText(t *testing.T) {
+	var files []string
+	for _, dir := range []string{".", "../handlers", "../middleware", "../health", "../openapi"} {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		require.NoError(t, err)
+		files = append(files, matches...)
+	}
+	require.NotEmpty(t, files)
+
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		require.NoError(t, err)
+		ast.Inspect(f, func(n ast.Node) bool {
+			lit, ok := n.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			for _, r := range lit.Value {
+				if unicode.Is(unicode.Cyrillic, r) {
+					t.Errorf("%s: untranslated text %s", fset.Position(lit.Pos()), lit.Value)
+					break
+				}
+			}
+			return true
+		})
+	}
+}
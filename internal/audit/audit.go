@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"messenger_frontend/internal/logging"
+)
+
+// Типы событий журнала аудита.
+const (
+	LoginSuccess = "login.success"
+	LoginFailure = "login.failure"
+	TokenInvalid = "auth.token_invalid"
+	AdminReload  = "admin.reload"
+	AdminAudit   = "admin.audit_query"
+	AdminDenied  = "admin.denied"
+)
+
+// Event — запись журнала аудита. Hash покрывает все поля записи и PrevHash, поэтому
+// изменение или удаление любой записи разрывает цепочку.
+type Event struct {
+	Seq       uint64            `json:"seq"`
+	Time      time.Time         `json:"time"`
+	Type      string            `json:"type"`
+	UserID    string            `json:"user_id,omitempty"`
+	Login     string            `json:"login,omitempty"`
+	RemoteIP  string            `json:"remote_ip,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Reason    string            `json:"reason,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	PrevHash  string            `json:"prev_hash"`
+	Hash      string            `json:"hash"`
+}
+
+// Filter отбирает записи для выдачи администратору. Нулевые поля не ограничивают выборку.
+type Filter struct {
+	UserID string
+	Type   string
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+func (f Filter) match(e Event) bool {
+	return (f.UserID == "" || e.UserID == f.UserID) &&
+		(f.Type == "" || e.Type == f.Type) &&
+		(f.From.IsZero() || !e.Time.Before(f.From)) &&
+		(f.To.IsZero() || e.Time.Before(f.To))
+}
+
+// Sink хранит цепочку записей. Append сам продолжает цепочку от последней сохранённой записи,
+// чтобы несколько экземпляров шлюза могли писать в общее хранилище.
+type Sink interface {
+	Append(ctx context.Context, e Event) (Event, error)
+	Query(ctx context.Context, f Filter) ([]Event, error)
+}
+
+// seal продолжает цепочку от prev: назначает номер, ссылку на предыдущий хеш и свой хеш.
+func seal(prev *Event, e Event) Event {
+	e.Seq, e.PrevHash = 1, ""
+	if prev != nil {
+		e.Seq, e.PrevHash = prev.Seq+1, prev.Hash
+	}
+	e.Hash = hash(e)
+	return e
+}
+
+func hash(e Event) string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify проверяет, что записи идут подряд и не изменены. Первая запись считается доверенной:
+// более ранние могли быть удалены ротацией.
+func Verify(events []Event) error {
+	for i, e := range events {
+		if hash(e) != e.Hash {
+			return fmt.Errorf("audit: record %d has been modified", e.Seq)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := events[i-1]
+		if e.PrevHash != prev.Hash || e.Seq != prev.Seq+1 {
+			return fmt.Errorf("audit: chain broken between records %d and %d", prev.Seq, e.Seq)
+		}
+	}
+	return nil
+}
+
+// Logger пишет события в Sink. Ошибка записи не должна ломать вход пользователя, поэтому
+// она только логируется. Нулевой *Logger ничего не записывает.
+type Logger struct {
+	sink Sink
+	now  func() time.Time
+}
+
+func New(sink Sink) *Logger {
+	return &Logger{sink: sink, now: time.Now}
+}
+
+// Record дополняет событие временем и идентификатором запроса, если их нет, и сохраняет его.
+func (l *Logger) Record(ctx context.Context, e Event) {
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = l.now().UTC()
+	}
+	if e.RequestID == "" {
+		e.RequestID = logging.RequestID(ctx)
+	}
+	if _, err := l.sink.Append(ctx, e); err != nil {
+		slog.ErrorContext(ctx, "audit: failed to record event", "type", e.Type, "error", err)
+	}
+}
+
+// RecordRequest записывает событие, связанное с HTTP-запросом, вместе с адресом клиента.
+func (l *Logger) RecordRequest(r *http.Request, e Event) {
+	e.RemoteIP = remoteIP(r)
+	l.Record(r.Context(), e)
+}
+
+func (l *Logger) Query(ctx context.Context, f Filter) ([]Event, error) {
+	return l.sink.Query(ctx, f)
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
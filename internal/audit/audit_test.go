@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSink_ChainSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	l := New(sink)
+	l.Record(context.Background(), Event{Type: LoginSuccess, UserID: "1"})
+	l.Record(context.Background(), Event{Type: LoginFailure, Login: "bob"})
+	require.NoError(t, sink.Close())
+
+	sink, err = NewFileSink(path)
+	require.NoError(t, err)
+	defer sink.Close()
+	New(sink).Record(context.Background(), Event{Type: AdminReload})
+
+	events, err := sink.Query(context.Background(), Filter{})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, uint64(3), events[2].Seq)
+	assert.Equal(t, events[1].Hash, events[2].PrevHash)
+	assert.NoError(t, Verify(events))
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	var events []Event
+	var prev *Event
+	for _, typ := range []string{LoginSuccess, LoginFailure, TokenInvalid} {
+		e := seal(prev, Event{Type: typ, Time: time.Now().UTC()})
+		events = append(events, e)
+		prev = &events[len(events)-1]
+	}
+	require.NoError(t, Verify(events))
+
+	modified := append([]Event(nil), events...)
+	modified[1].Login = "mallory"
+	assert.ErrorContains(t, Verify(modified), "record 2 has been modified")
+
+	removed := []Event{events[0], events[2]}
+	assert.ErrorContains(t, Verify(removed), "chain broken")
+}
+
+func TestQueryHandler(t *testing.T) {
+	sink, err := NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+	require.NoError(t, err)
+	defer sink.Close()
+	l := New(sink)
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	l.Record(context.Background(), Event{Type: LoginSuccess, UserID: "1"})
+	now = now.Add(time.Hour)
+	l.Record(context.Background(), Event{Type: LoginSuccess, UserID: "2"})
+	l.Record(context.Background(), Event{Type: LoginSuccess, UserID: "1"})
+
+	rr := httptest.NewRecorder()
+	QueryHandler(l).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/audit?user_id=1&from=2025-07-01T12:30:00Z", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp queryResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, uint64(3), resp.Events[0].Seq)
+	assert.Nil(t, resp.Verified)
+
+	// Сам запрос к журналу тоже попадает в журнал
+	rr = httptest.NewRecorder()
+	QueryHandler(l).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/audit", nil))
+	resp = queryResponse{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Len(t, resp.Events, 4)
+	assert.Equal(t, AdminAudit, resp.Events[3].Type)
+	require.NotNil(t, resp.Verified)
+	assert.True(t, *resp.Verified)
+
+	rr = httptest.NewRecorder()
+	QueryHandler(l).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/audit?from=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestThrottle_AggregatesPerAddress(t *testing.T) {
+	sink, err := NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+	require.NoError(t, err)
+	defer sink.Close()
+	throttle := NewThrottle(New(sink), time.Minute, 16)
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	throttle.now = func() time.Time { return now }
+
+	request := func(addr string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/v1/dialogs", nil)
+		r.RemoteAddr = addr
+		return r
+	}
+	for range 5 {
+		throttle.RecordRequest(request("10.0.0.1:1000"), Event{Type: TokenInvalid})
+	}
+	throttle.RecordRequest(request("10.0.0.2:1000"), Event{Type: TokenInvalid})
+	now = now.Add(time.Minute)
+	throttle.RecordRequest(request("10.0.0.1:2000"), Event{Type: TokenInvalid})
+	throttle.Close()
+
+	events, err := sink.Query(context.Background(), Filter{})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, "10.0.0.1", events[0].RemoteIP)
+	assert.Equal(t, "10.0.0.2", events[1].RemoteIP)
+	// Следующая запись с адреса сообщает, сколько событий было пропущено
+	assert.Equal(t, "10.0.0.1", events[2].RemoteIP)
+	assert.Equal(t, "4", events[2].Details["suppressed"])
+	assert.Equal(t, now, events[2].Time)
+
+	var nilThrottle *Throttle
+	nilThrottle.RecordRequest(request("10.0.0.1:1000"), Event{Type: TokenInvalid})
+	nilThrottle.Close()
+}
@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileSink дописывает записи строками JSON в файл. Файл должен принадлежать одному процессу:
+// последняя запись читается при открытии и дальше хранится в памяти.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	last *Event
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	s := &FileSink{path: path}
+	events, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	if len(events) > 0 {
+		s.last = &events[len(events)-1]
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("audit file: %w", err)
+	}
+	s.f = f
+	return s, nil
+}
+
+func (s *FileSink) Append(_ context.Context, e Event) (Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e = seal(s.last, e)
+	data, err := json.Marshal(e)
+	if err != nil {
+		return Event{}, err
+	}
+	if _, err := s.f.Write(append(data, '\n')); err != nil {
+		return Event{}, fmt.Errorf("audit file: %w", err)
+	}
+	s.last = &e
+	return e, nil
+}
+
+func (s *FileSink) Query(_ context.Context, f Filter) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	var out []Event
+	for _, e := range events {
+		if f.match(e) {
+			out = append(out, e)
+		}
+	}
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out, nil
+}
+
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
+
+func (s *FileSink) read() ([]Event, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("audit file: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("audit file: record %d: %w", len(events)+1, err)
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+)
+
+type queryResponse struct {
+	Events []Event `json:"events"`
+	// Verified заполняется только для выборки без фильтров по пользователю и типу:
+	// в отфильтрованной выборке записи идут не подряд и цепочку проверить нельзя.
+	Verified *bool  `json:"verified,omitempty"`
+	Error    string `json:"chain_error,omitempty"`
+}
+
+// QueryHandler обслуживает GET /admin/audit?user_id=&type=&from=&to=&limit=.
+// from и to задаются в RFC 3339, выдаются последние limit подходящих записей.
+func QueryHandler(l *Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		f, err := parseFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		events, err := l.Query(r.Context(), f)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "audit query failed", "error", err)
+			http.Error(w, "audit query failed", http.StatusInternalServerError)
+			return
+		}
+		l.RecordRequest(r, Event{Type: AdminAudit, UserID: f.UserID, Details: map[string]string{"query": r.URL.RawQuery}})
+
+		resp := queryResponse{Events: events}
+		if resp.Events == nil {
+			resp.Events = []Event{}
+		}
+		if f.UserID == "" && f.Type == "" {
+			err := Verify(events)
+			ok := err == nil
+			resp.Verified = &ok
+			if err != nil {
+				resp.Error = err.Error()
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func parseFilter(r *http.Request) (Filter, error) {
+	q := r.URL.Query()
+	f := Filter{UserID: q.Get("user_id"), Type: q.Get("type"), Limit: defaultQueryLimit}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return Filter{}, errors.New(p.name + " must be RFC 3339")
+			}
+			*p.dst = t
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxQueryLimit {
+			return Filter{}, errors.New("limit must be between 1 and " + strconv.Itoa(maxQueryLimit))
+		}
+		f.Limit = n
+	}
+	return f, nil
+}
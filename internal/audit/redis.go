@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+const (
+	eventField    = "event"
+	appendRetries = 10
+	queryBatch    = 500
+)
+
+// RedisSink хранит записи в Redis Stream. Цепочка общая для всех экземпляров шлюза:
+// последняя запись читается и новая добавляется в одной транзакции под WATCH.
+type RedisSink struct {
+	rdb    redis.UniversalClient
+	stream string
+	maxLen int64
+}
+
+// NewRedisSink пишет в stream; maxLen > 0 ограничивает его длину приблизительно (MAXLEN ~).
+func NewRedisSink(rdb redis.UniversalClient, stream string, maxLen int64) *RedisSink {
+	return &RedisSink{rdb: rdb, stream: stream, maxLen: maxLen}
+}
+
+func (s *RedisSink) Append(ctx context.Context, e Event) (Event, error) {
+	for range appendRetries {
+		var sealed Event
+		err := s.rdb.Watch(ctx, func(tx *redis.Tx) error {
+			msgs, err := tx.XRevRangeN(ctx, s.stream, "+", "-", 1).Result()
+			if err != nil {
+				return err
+			}
+			var prev *Event
+			if len(msgs) > 0 {
+				last, err := decode(msgs[0])
+				if err != nil {
+					return err
+				}
+				prev = &last
+			}
+			sealed = seal(prev, e)
+			data, err := json.Marshal(sealed)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+				return p.XAdd(ctx, &redis.XAddArgs{
+					Stream: s.stream,
+					MaxLen: s.maxLen,
+					Approx: s.maxLen > 0,
+					Values: map[string]any{eventField: data},
+				}).Err()
+			})
+			return err
+		}, s.stream)
+		if errors.Is(err, redis.TxFailedErr) {
+			// Другой экземпляр успел дописать запись, продолжаем цепочку от неё
+			continue
+		}
+		if err != nil {
+			return Event{}, fmt.Errorf("audit stream: %w", err)
+		}
+		return sealed, nil
+	}
+	return Event{}, fmt.Errorf("audit stream: too much contention on %s", s.stream)
+}
+
+// Query идёт по stream от новых записей к старым, пока не наберёт Limit подходящих.
+func (s *RedisSink) Query(ctx context.Context, f Filter) ([]Event, error) {
+	start, stop := "+", "-"
+	if !f.To.IsZero() {
+		start = strconv.FormatInt(f.To.UnixMilli(), 10)
+	}
+	if !f.From.IsZero() {
+		stop = strconv.FormatInt(f.From.UnixMilli(), 10)
+	}
+
+	var out []Event
+	for {
+		msgs, err := s.rdb.XRevRangeN(ctx, s.stream, start, stop, queryBatch).Result()
+		if err != nil {
+			return nil, fmt.Errorf("audit stream: %w", err)
+		}
+		for _, msg := range msgs {
+			e, err := decode(msg)
+			if err != nil {
+				return nil, err
+			}
+			if f.match(e) {
+				out = append(out, e)
+			}
+			if f.Limit > 0 && len(out) == f.Limit {
+				slices.Reverse(out)
+				return out, nil
+			}
+		}
+		if len(msgs) < queryBatch {
+			break
+		}
+		start = "(" + msgs[len(msgs)-1].ID
+	}
+	slices.Reverse(out)
+	return out, nil
+}
+
+func decode(msg redis.XMessage) (Event, error) {
+	var e Event
+	raw, _ := msg.Values[eventField].(string)
+	if err := json.Unmarshal([]byte(raw), &e); err != nil {
+		return Event{}, fmt.Errorf("audit stream: entry %s: %w", msg.ID, err)
+	}
+	return e, nil
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-redis/redismock/v9"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisSink_AppendContinuesChain(t *testing.T) {
+	rdb, mock := redismock.NewClientMock()
+	prev := seal(nil, Event{Type: LoginSuccess, UserID: "1"})
+	prevData, _ := json.Marshal(prev)
+	next := seal(&prev, Event{Type: LoginFailure, Login: "bob"})
+	nextData, _ := json.Marshal(next)
+
+	mock.ExpectWatch("gateway:audit")
+	mock.ExpectXRevRangeN("gateway:audit", "+", "-", 1).SetVal([]redis.XMessage{
+		{ID: "1-0", Values: map[string]any{eventField: string(prevData)}},
+	})
+	mock.ExpectTxPipeline()
+	mock.ExpectXAdd(&redis.XAddArgs{
+		Stream: "gateway:audit",
+		MaxLen: 100,
+		Approx: true,
+		Values: map[string]any{eventField: nextData},
+	}).SetVal("2-0")
+	mock.ExpectTxPipelineExec()
+
+	sink := NewRedisSink(rdb, "gateway:audit", 100)
+	got, err := sink.Append(context.Background(), Event{Type: LoginFailure, Login: "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), got.Seq)
+	assert.Equal(t, prev.Hash, got.PrevHash)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedisSink_QueryFiltersByUser(t *testing.T) {
+	rdb, mock := redismock.NewClientMock()
+	var msgs []redis.XMessage
+	var prev *Event
+	for _, user := range []string{"1", "2", "1"} {
+		e := seal(prev, Event{Type: LoginSuccess, UserID: user})
+		prev = &e
+		data, _ := json.Marshal(e)
+		// XREVRANGE отдаёт записи от новых к старым
+		msgs = append([]redis.XMessage{{ID: "x", Values: map[string]any{eventField: string(data)}}}, msgs...)
+	}
+	mock.ExpectXRevRangeN("gateway:audit", "+", "-", queryBatch).SetVal(msgs)
+
+	events, err := NewRedisSink(rdb, "gateway:audit", 0).Query(context.Background(), Filter{UserID: "1"})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, uint64(1), events[0].Seq)
+	assert.Equal(t, uint64(3), events[1].Seq)
+}
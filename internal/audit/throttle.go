@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"messenger_frontend/internal/logging"
+)
+
+// maxThrottleSources ограничивает число адресов, для которых помнится окно: иначе клиент,
+// перебирающий адреса, раздувал бы карту без предела.
+const maxThrottleSources = 10000
+
+// Throttle записывает события, которые может вызвать любой неаутентифицированный клиент,
+// например auth.token_invalid. Запись идёт в фоне через очередь ограниченной длины, поэтому
+// ответ клиенту не ждёт продолжения цепочки в Sink. С одного адреса за interval пишется одно
+// событие; остальные только считаются, и их число попадает в details.suppressed следующей
+// записи с этого адреса. При переполненной очереди события отбрасываются.
+// Нулевой *Throttle ничего не записывает.
+type Throttle struct {
+	logger   *Logger
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	windows map[string]*throttleWindow
+	dropped uint64
+
+	queue chan Event
+	done  chan struct{}
+}
+
+type throttleWindow struct {
+	start      time.Time
+	suppressed int
+}
+
+// NewThrottle запускает фоновую запись в l. Для nil l возвращает nil.
+func NewThrottle(l *Logger, interval time.Duration, queueSize int) *Throttle {
+	if l == nil {
+		return nil
+	}
+	t := &Throttle{
+		logger:   l,
+		interval: interval,
+		now:      time.Now,
+		windows:  make(map[string]*throttleWindow),
+		queue:    make(chan Event, queueSize),
+		done:     make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+func (t *Throttle) run() {
+	defer close(t.done)
+	for e := range t.queue {
+		t.logger.Record(context.Background(), e)
+	}
+}
+
+// RecordRequest ставит событие в очередь, если для адреса клиента не исчерпано окно.
+func (t *Throttle) RecordRequest(r *http.Request, e Event) {
+	if t == nil {
+		return
+	}
+	e.RemoteIP = remoteIP(r)
+	now := t.now()
+
+	t.mu.Lock()
+	suppressed, ok := t.admit(e.Type+" "+e.RemoteIP, now)
+	t.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	e.Time = now.UTC()
+	e.RequestID = logging.RequestID(r.Context())
+	if suppressed > 0 {
+		details := make(map[string]string, len(e.Details)+1)
+		for k, v := range e.Details {
+			details[k] = v
+		}
+		details["suppressed"] = strconv.Itoa(suppressed)
+		e.Details = details
+	}
+
+	select {
+	case t.queue <- e:
+	default:
+		t.drop()
+	}
+}
+
+// admit решает, пишется ли событие с ключом key; suppressed — сколько событий с этим ключом
+// было пропущено в прошлом окне. Вызывается под t.mu.
+func (t *Throttle) admit(key string, now time.Time) (suppressed int, ok bool) {
+	w := t.windows[key]
+	if w != nil && now.Sub(w.start) < t.interval {
+		w.suppressed++
+		return 0, false
+	}
+	if w == nil && len(t.windows) >= maxThrottleSources {
+		for k, old := range t.windows {
+			if now.Sub(old.start) >= t.interval {
+				delete(t.windows, k)
+			}
+		}
+		if len(t.windows) >= maxThrottleSources {
+			t.dropped++
+			return 0, false
+		}
+	}
+	if w != nil {
+		suppressed = w.suppressed
+	}
+	t.windows[key] = &throttleWindow{start: now}
+	return suppressed, true
+}
+
+func (t *Throttle) drop() {
+	t.mu.Lock()
+	t.dropped++
+	n := t.dropped
+	t.mu.Unlock()
+	// Под нагрузкой очередь переполняется постоянно, поэтому в лог попадает лишь часть случаев
+	if n&(n-1) == 0 {
+		slog.Warn("audit: queue is full, events dropped", "dropped_total", n)
+	}
+}
+
+// Close дописывает очередь и останавливает фоновую запись. После Close RecordRequest
+// вызывать нельзя: шлюз закрывает Throttle, когда HTTP-сервер уже остановлен.
+func (t *Throttle) Close() {
+	if t == nil {
+		return
+	}
+	close(t.queue)
+	<-t.done
+}
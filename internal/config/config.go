@@ -0,0 +1,990 @@
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"messenger_frontend/internal/tlsutil"
+)
+
+// Secret — строковое значение, которое не должно попадать в логи и вывод конфигурации.
+type Secret string
+
+const redacted = "[REDACTED]"
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// Value возвращает настоящее значение секрета.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+type Config struct {
+	HTTP        HTTPConfig        `yaml:"http"`
+	Upstreams   UpstreamsConfig   `yaml:"upstreams"`
+	Redis       RedisConfig       `yaml:"redis"`
+	JWT         JWTConfig         `yaml:"jwt"`
+	Timeouts    TimeoutsConfig    `yaml:"timeouts"`
+	Limiter     LimiterConfig     `yaml:"limiter"`
+	Shutdown    ShutdownConfig    `yaml:"shutdown"`
+	Health      HealthConfig      `yaml:"health"`
+	Admin       AdminConfig       `yaml:"admin"`
+	Log         LogConfig         `yaml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Audit       AuditConfig       `yaml:"audit"`
+	AccessLog   AccessLogConfig   `yaml:"access_log"`
+	API         APIConfig         `yaml:"api"`
+	Compression CompressionConfig `yaml:"compression"`
+}
+
+type HTTPConfig struct {
+	Addr         string          `yaml:"addr"`
+	ReadTimeout  time.Duration   `yaml:"read_timeout"`
+	WriteTimeout time.Duration   `yaml:"write_timeout"`
+	IdleTimeout  time.Duration   `yaml:"idle_timeout"`
+	TLS          ServerTLSConfig `yaml:"tls"`
+	// H2C включает HTTP/2 без шифрования для внутреннего трафика; несовместим с TLS.
+	H2C bool `yaml:"h2c"`
+	// RedirectAddr — адрес listener, перенаправляющего HTTP на HTTPS. Пусто — не запускать.
+	RedirectAddr string `yaml:"redirect_addr"`
+}
+
+type ServerTLSConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// MinVersion — "1.2" или "1.3".
+	MinVersion string `yaml:"min_version"`
+	// CipherSuites — имена наборов шифров для TLS 1.2; пусто — набор Go по умолчанию.
+	CipherSuites []string `yaml:"cipher_suites"`
+	// ReloadInterval — как часто проверять файлы сертификата на изменения.
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+type UpstreamsConfig struct {
+	Dialogs       GRPCUpstreamConfig `yaml:"dialogs"`
+	Users         GRPCUpstreamConfig `yaml:"users"`
+	Notifications string             `yaml:"notifications"`
+}
+
+// GRPCUpstreamConfig описывает подключение к gRPC-сервису. В YAML вместо объекта
+// можно указать просто адрес: `dialogs: "dialog_service:9001"`.
+type GRPCUpstreamConfig struct {
+	Addr string `yaml:"addr"`
+	// Discovery — откуда брать реплики: dns (все адреса имени из addr), static (endpoints)
+	// или file (по адресу в строке).
+	Discovery string   `yaml:"discovery"`
+	Endpoints []string `yaml:"endpoints"`
+	File      string   `yaml:"file"`
+	// RefreshInterval — как часто перечитывать DNS и файл.
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+	// Balancer — round_robin, least_request или pick_first.
+	Balancer    string          `yaml:"balancer"`
+	HealthCheck bool            `yaml:"health_check"`
+	Outlier     OutlierConfig   `yaml:"outlier"`
+	Keepalive   KeepaliveConfig `yaml:"keepalive"`
+	TLS         ClientTLSConfig `yaml:"tls"`
+}
+
+// Способы обнаружения реплик.
+const (
+	DiscoveryDNS    = "dns"
+	DiscoveryStatic = "static"
+	DiscoveryFile   = "file"
+)
+
+// Target возвращает gRPC-адрес для выбранного способа обнаружения.
+func (u GRPCUpstreamConfig) Target() string {
+	switch u.Discovery {
+	case DiscoveryStatic:
+		return "static:///" + strings.Join(u.Endpoints, ",")
+	case DiscoveryFile:
+		return "file://" + u.File
+	default:
+		return "dns:///" + u.Addr
+	}
+}
+
+// OutlierConfig — временное исключение реплики после нескольких ошибок подряд.
+type OutlierConfig struct {
+	// ConsecutiveFailures — сколько ошибок Unavailable/DeadlineExceeded/Internal/Unknown подряд
+	// приводят к исключению; 0 отключает исключение.
+	ConsecutiveFailures int           `yaml:"consecutive_failures"`
+	BaseEjectionTime    time.Duration `yaml:"base_ejection_time"`
+	MaxEjectionPercent  int           `yaml:"max_ejection_percent"`
+}
+
+// KeepaliveConfig — HTTP/2 ping для обнаружения оборванных соединений. Time 0 отключает ping;
+// сервер должен разрешать такую частоту (grpc.KeepaliveEnforcementPolicy), иначе закроет соединение.
+type KeepaliveConfig struct {
+	Time                time.Duration `yaml:"time"`
+	Timeout             time.Duration `yaml:"timeout"`
+	PermitWithoutStream bool          `yaml:"permit_without_stream"`
+}
+
+func (u GRPCUpstreamConfig) validate(prefix string) []error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(prefix+": "+format, args...))
+	}
+	switch u.Discovery {
+	case DiscoveryDNS:
+		if _, _, err := net.SplitHostPort(u.Addr); err != nil {
+			add("addr must be host:port")
+		}
+	case DiscoveryStatic:
+		if len(u.Endpoints) == 0 {
+			add("static discovery requires endpoints")
+		}
+		for _, e := range u.Endpoints {
+			if _, _, err := net.SplitHostPort(e); err != nil {
+				add("endpoint %q must be host:port", e)
+			}
+		}
+	case DiscoveryFile:
+		if !filepath.IsAbs(u.File) {
+			add("file must be an absolute path")
+		} else if _, err := os.Stat(u.File); err != nil {
+			add("%v", err)
+		}
+	default:
+		add("unknown discovery %q (want dns, static or file)", u.Discovery)
+	}
+	if u.RefreshInterval <= 0 {
+		add("refresh_interval must be positive")
+	}
+	switch u.Balancer {
+	case "round_robin", "least_request", "pick_first":
+	default:
+		add("unknown balancer %q (want round_robin, least_request or pick_first)", u.Balancer)
+	}
+	if o := u.Outlier; o.ConsecutiveFailures < 0 || o.MaxEjectionPercent < 0 || o.MaxEjectionPercent > 100 ||
+		(o.ConsecutiveFailures > 0 && o.BaseEjectionTime <= 0) {
+		add("outlier: consecutive_failures >= 0, base_ejection_time > 0 and max_ejection_percent in 0..100 required")
+	}
+	if u.Keepalive.Time < 0 || u.Keepalive.Timeout < 0 {
+		add("keepalive durations must not be negative")
+	}
+	// С static и file authority — список адресов или путь, а не имя хоста: сертификат
+	// с ним не совпадёт, поэтому имя для проверки нужно указать явно
+	if u.TLS.Enabled && u.TLS.ServerName == "" && u.Discovery != DiscoveryDNS {
+		add("tls.server_name is required with %s discovery", u.Discovery)
+	}
+	return append(errs, u.TLS.validate(prefix+".tls")...)
+}
+
+func (u *GRPCUpstreamConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&u.Addr)
+	}
+	type plain GRPCUpstreamConfig
+	return node.Decode((*plain)(u))
+}
+
+// ClientTLSConfig — TLS для исходящего подключения. Без ca_file используются системные CA,
+// cert_file и key_file вместе включают mTLS.
+type ClientTLSConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	CAFile     string `yaml:"ca_file"`
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	ServerName string `yaml:"server_name"`
+	// ReloadInterval — как часто проверять файлы сертификатов на изменения.
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+func (c ClientTLSConfig) validate(prefix string) []error {
+	if !c.Enabled {
+		return nil
+	}
+	var errs []error
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		errs = append(errs, fmt.Errorf("%s: cert_file and key_file must be set together", prefix))
+	}
+	for _, f := range []string{c.CAFile, c.CertFile, c.KeyFile} {
+		if f == "" {
+			continue
+		}
+		if _, err := os.Stat(f); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", prefix, err))
+		}
+	}
+	if c.ReloadInterval <= 0 {
+		errs = append(errs, fmt.Errorf("%s: reload_interval must be positive", prefix))
+	}
+	return errs
+}
+
+// Топологии Redis.
+const (
+	RedisStandalone = "standalone"
+	RedisSentinel   = "sentinel"
+	RedisCluster    = "cluster"
+)
+
+type RedisConfig struct {
+	// Mode — топология: standalone, sentinel или cluster.
+	Mode string `yaml:"mode"`
+	// Addr — адрес сервера в режиме standalone.
+	Addr string `yaml:"addr"`
+	// Addrs — адреса Sentinel или начальных узлов кластера.
+	Addrs []string `yaml:"addrs"`
+	// MasterName — имя группы, за которой следят Sentinel.
+	MasterName string `yaml:"master_name"`
+	// Username задаёт пользователя Redis ACL; без него AUTH выполняется только с паролем.
+	Username         string `yaml:"username"`
+	Password         Secret `yaml:"password"`
+	SentinelUsername string `yaml:"sentinel_username"`
+	SentinelPassword Secret `yaml:"sentinel_password"`
+	DB               int    `yaml:"db"`
+
+	TLS ClientTLSConfig `yaml:"tls"`
+
+	// PoolSize — соединений на узел; 0 означает значение go-redis (10 на CPU).
+	PoolSize     int           `yaml:"pool_size"`
+	MinIdleConns int           `yaml:"min_idle_conns"`
+	DialTimeout  time.Duration `yaml:"dial_timeout"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	// PoolTimeout — сколько ждать свободного соединения, когда пул исчерпан.
+	PoolTimeout time.Duration `yaml:"pool_timeout"`
+}
+
+func (c RedisConfig) validate() []error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	switch c.Mode {
+	case RedisStandalone:
+		if c.Addr == "" {
+			add("redis.addr is required")
+		}
+	case RedisSentinel:
+		if len(c.Addrs) == 0 || c.MasterName == "" {
+			add("redis: sentinel mode requires addrs and master_name")
+		}
+	case RedisCluster:
+		if len(c.Addrs) == 0 {
+			add("redis: cluster mode requires addrs")
+		}
+		if c.DB != 0 {
+			add("redis.db must be 0 in cluster mode")
+		}
+	default:
+		add("redis.mode: unknown mode %q (want standalone, sentinel or cluster)", c.Mode)
+	}
+	if c.DB < 0 {
+		add("redis.db must not be negative")
+	}
+	if c.PoolSize < 0 || c.MinIdleConns < 0 {
+		add("redis pool sizes must not be negative")
+	}
+	if c.DialTimeout <= 0 || c.ReadTimeout <= 0 || c.WriteTimeout <= 0 || c.PoolTimeout <= 0 {
+		add("redis timeouts must be positive")
+	}
+	return append(errs, c.TLS.validate("redis.tls")...)
+}
+
+type JWTConfig struct {
+	Secret Secret `yaml:"secret"`
+}
+
+type TimeoutsConfig struct {
+	Default time.Duration            `yaml:"default"`
+	Routes  map[string]time.Duration `yaml:"routes"`
+}
+
+type ShutdownConfig struct {
+	// DrainDelay — пауза между провалом readiness и закрытием listener, чтобы балансировщик
+	// успел перестать присылать новые запросы.
+	DrainDelay time.Duration `yaml:"drain_delay"`
+	// Timeout — сколько ждать завершения запросов в обработке.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type AdminConfig struct {
+	// Addr — служебный listener с pprof, метриками и диагностикой. Пустой адрес его отключает.
+	// Наружу его открывать не следует: эндпоинты, кроме /admin/reload и /admin/audit, не требуют токена.
+	Addr string `yaml:"addr"`
+	// Token открывает POST /admin/reload и GET /admin/audit (заголовок X-Admin-Token).
+	// Пустой токен их отключает.
+	Token Secret `yaml:"token"`
+}
+
+type LogConfig struct {
+	// Level — debug, info, warn или error. На debug в лог попадает каждый вызов upstream.
+	Level string `yaml:"level"`
+}
+
+const (
+	TracingNone   = "none"
+	TracingOTLP   = "otlp"
+	TracingStdout = "stdout"
+	TracingFile   = "file"
+)
+
+type TracingConfig struct {
+	// Exporter — none, otlp (gRPC), stdout или file. При none входящий traceparent всё равно
+	// передаётся в upstream, но спаны шлюза не записываются.
+	Exporter string `yaml:"exporter"`
+	// Endpoint — адрес OTLP-коллектора host:port. Пустой берётся из OTEL_EXPORTER_OTLP_ENDPOINT.
+	Endpoint string `yaml:"endpoint"`
+	// Insecure отключает TLS до коллектора.
+	Insecure bool `yaml:"insecure"`
+	// File — куда экспортёр file пишет спаны в JSON.
+	File string `yaml:"file"`
+	// SampleRatio — доля записываемых трасс, от 0 до 1.
+	SampleRatio float64 `yaml:"sample_ratio"`
+	// ParentBased — следовать решению о семплировании из входящего traceparent, если он есть.
+	ParentBased bool `yaml:"parent_based"`
+	// ServiceName — значение service.name в ресурсе трассировки.
+	ServiceName string `yaml:"service_name"`
+}
+
+func (c TracingConfig) validate() []error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	switch c.Exporter {
+	case TracingNone, TracingOTLP, TracingStdout:
+	case TracingFile:
+		if c.File == "" {
+			add("tracing.file is required for the file exporter")
+		}
+	default:
+		add("tracing.exporter: unknown exporter %q", c.Exporter)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		add("tracing.sample_ratio must be in [0, 1]")
+	}
+	if c.ServiceName == "" {
+		add("tracing.service_name is required")
+	}
+	return errs
+}
+
+const (
+	AuditNone  = "none"
+	AuditFile  = "file"
+	AuditRedis = "redis"
+)
+
+type AuditConfig struct {
+	// Sink — none, file или redis. Журнал аудита пишется отдельно от обычных логов.
+	Sink string `yaml:"sink"`
+	// File — файл для sink file; писать в него должен только один экземпляр шлюза.
+	File string `yaml:"file"`
+	// Stream — Redis Stream для sink redis, общий для всех экземпляров.
+	Stream string `yaml:"stream"`
+	// MaxLen приблизительно ограничивает длину stream; 0 — без ограничения.
+	MaxLen int64 `yaml:"max_len"`
+	// TokenInvalidInterval — окно, в котором с одного адреса записывается одно событие
+	// auth.token_invalid; остальные только считаются.
+	TokenInvalidInterval time.Duration `yaml:"token_invalid_interval"`
+	// QueueSize — длина очереди фоновой записи auth.token_invalid; при переполнении события теряются.
+	QueueSize int `yaml:"queue_size"`
+}
+
+func (c AuditConfig) validate() []error {
+	var errs []error
+	switch c.Sink {
+	case AuditNone:
+	case AuditFile:
+		if c.File == "" {
+			errs = append(errs, errors.New("audit.file is required for the file sink"))
+		}
+	case AuditRedis:
+		if c.Stream == "" {
+			errs = append(errs, errors.New("audit.stream is required for the redis sink"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("audit.sink: unknown sink %q", c.Sink))
+	}
+	if c.MaxLen < 0 {
+		errs = append(errs, errors.New("audit.max_len must not be negative"))
+	}
+	if c.TokenInvalidInterval < 0 {
+		errs = append(errs, errors.New("audit.token_invalid_interval must not be negative"))
+	}
+	if c.Sink != AuditNone && c.QueueSize < 1 {
+		errs = append(errs, errors.New("audit.queue_size must be positive"))
+	}
+	return errs
+}
+
+type APIConfig struct {
+	// LegacyDeprecated — с этого момента маршруты без /v1 отвечают с заголовком Deprecation.
+	LegacyDeprecated time.Time `yaml:"legacy_deprecated"`
+	// LegacySunset — дата удаления маршрутов без /v1 для заголовка Sunset; пустая не выводится.
+	// По умолчанию не задана: дата отключения объявляется оператором.
+	LegacySunset time.Time `yaml:"legacy_sunset"`
+	// Docs публикует документ OpenAPI на /openapi.json и страницу документации на /docs.
+	Docs bool `yaml:"docs"`
+	// ValidateRequests отклоняет запросы, не соответствующие документу OpenAPI, ответом 400.
+	ValidateRequests bool `yaml:"validate_requests"`
+	// ValidateResponses сверяет ответы с документом и пишет расхождения в лог; для стендов.
+	ValidateResponses bool `yaml:"validate_responses"`
+	// DefaultPageSize — размер страницы списков диалогов и сообщений без параметра limit.
+	DefaultPageSize int `yaml:"default_page_size"`
+	// MaxPageSize — наибольший limit; запросы с большим значением получают 400.
+	MaxPageSize int `yaml:"max_page_size"`
+	// CursorSecret подписывает курсоры пагинации. Пустой — ключ выводится из jwt.secret.
+	CursorSecret Secret `yaml:"cursor_secret"`
+}
+
+// maxPageSizeLimit — потолок для api.max_page_size, чтобы один запрос не выгружал диалог целиком.
+const maxPageSizeLimit = 1000
+
+// AccessLogFormats — форматы журнала доступа.
+var AccessLogFormats = []string{"common", "combined", "json"}
+
+type AccessLogConfig struct {
+	// Format — common, combined или json.
+	Format string `yaml:"format"`
+	// Output — stdout, stderr, путь к файлу или none, чтобы отключить журнал.
+	Output string `yaml:"output"`
+	// SampleRate — доля записываемых запросов от 0 до 1, Routes переопределяет её для маршрутов.
+	// Медленные запросы и ответы 5xx пишутся всегда.
+	SampleRate float64            `yaml:"sample_rate"`
+	Routes     map[string]float64 `yaml:"routes"`
+	// SlowThreshold — запросы не быстрее порога пишутся всегда; 0 отключает правило.
+	SlowThreshold time.Duration `yaml:"slow_threshold"`
+}
+
+func (c AccessLogConfig) validate() []error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	if !slices.Contains(AccessLogFormats, c.Format) {
+		add("access_log.format must be one of %s", strings.Join(AccessLogFormats, ", "))
+	}
+	if c.Output == "" {
+		add("access_log.output is required")
+	}
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		add("access_log.sample_rate must be in [0, 1]")
+	}
+	for route, rate := range c.Routes {
+		if rate < 0 || rate > 1 {
+			add("access_log.routes[%s] must be in [0, 1]", route)
+		}
+	}
+	if c.SlowThreshold < 0 {
+		add("access_log.slow_threshold must not be negative")
+	}
+	return errs
+}
+
+// CompressionEncodings — кодировки, которыми шлюз умеет сжимать ответы.
+var CompressionEncodings = []string{"zstd", "br", "gzip"}
+
+type CompressionConfig struct {
+	// Enabled включает сжатие ответов по Accept-Encoding.
+	Enabled bool `yaml:"enabled"`
+	// Encodings — кодировки в порядке предпочтения при равном q у клиента.
+	Encodings []string `yaml:"encodings"`
+	// MinSize — ответы короче, в байтах, отдаются без сжатия.
+	MinSize int `yaml:"min_size"`
+	// ContentTypes — сжимаемые типы; "text/*" разрешает все подтипы.
+	ContentTypes []string `yaml:"content_types"`
+	// ExcludeRoutes — шаблоны маршрутов, ответы которых не сжимаются, например long-poll.
+	ExcludeRoutes []string `yaml:"exclude_routes"`
+}
+
+func (c CompressionConfig) validate() []error {
+	if !c.Enabled {
+		return nil
+	}
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	if len(c.Encodings) == 0 {
+		add("compression.encodings must not be empty")
+	}
+	for _, enc := range c.Encodings {
+		if !slices.Contains(CompressionEncodings, enc) {
+			add("compression.encodings: %q is not one of %s", enc, strings.Join(CompressionEncodings, ", "))
+		}
+	}
+	if c.MinSize < 0 {
+		add("compression.min_size must not be negative")
+	}
+	if len(c.ContentTypes) == 0 {
+		add("compression.content_types must not be empty")
+	}
+	return errs
+}
+
+// HealthDependencies — зависимости, которые умеет проверять /readyz.
+var HealthDependencies = []string{"redis", "dialogs", "users", "notifications"}
+
+type HealthConfig struct {
+	// Timeout ограничивает каждую проверку зависимости.
+	Timeout time.Duration `yaml:"timeout"`
+	// CheckNotifications включает проверку доступности notifications-сервиса.
+	CheckNotifications bool `yaml:"check_notifications"`
+	// Critical — зависимости, без которых шлюз считается неготовым.
+	Critical []string `yaml:"critical"`
+}
+
+// IsCritical сообщает, входит ли зависимость в список критичных.
+func (h HealthConfig) IsCritical(name string) bool {
+	return slices.Contains(h.Critical, name)
+}
+
+type LimiterConfig struct {
+	InitialLimit  int           `yaml:"initial_limit"`
+	MinLimit      int           `yaml:"min_limit"`
+	MaxLimit      int           `yaml:"max_limit"`
+	TargetLatency time.Duration `yaml:"target_latency"`
+	BackoffRatio  float64       `yaml:"backoff_ratio"`
+}
+
+// Default возвращает конфигурацию, совпадающую с прежними захардкоженными значениями.
+func Default() Config {
+	return Config{
+		HTTP: HTTPConfig{
+			Addr:         ":8080",
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 35 * time.Second,
+			IdleTimeout:  60 * time.Second,
+			TLS: ServerTLSConfig{
+				MinVersion:     "1.2",
+				ReloadInterval: 30 * time.Second,
+			},
+		},
+		Upstreams: UpstreamsConfig{
+			Dialogs:       defaultGRPCUpstream("dialog_service:9001"),
+			Users:         defaultGRPCUpstream("users_service:9000"),
+			Notifications: "http://notifications:8082/notifications",
+		},
+		Redis: RedisConfig{
+			Mode:         RedisStandalone,
+			Addr:         "redis:6379",
+			TLS:          ClientTLSConfig{ReloadInterval: 30 * time.Second},
+			DialTimeout:  5 * time.Second,
+			ReadTimeout:  3 * time.Second,
+			WriteTimeout: 3 * time.Second,
+			PoolTimeout:  4 * time.Second,
+		},
+		Timeouts: TimeoutsConfig{
+			Default: 5 * time.Second,
+			Routes: map[string]time.Duration{
+				"/notifications/longpoll": 30 * time.Second,
+			},
+		},
+		Limiter: LimiterConfig{
+			InitialLimit:  100,
+			MinLimit:      10,
+			MaxLimit:      1000,
+			TargetLatency: time.Second,
+			BackoffRatio:  0.9,
+		},
+		Shutdown: ShutdownConfig{
+			DrainDelay: 5 * time.Second,
+			Timeout:    20 * time.Second,
+		},
+		Health: HealthConfig{
+			Timeout:  time.Second,
+			Critical: []string{"redis", "dialogs", "users"},
+		},
+		Admin: AdminConfig{Addr: "127.0.0.1:9090"},
+		Log:   LogConfig{Level: "info"},
+		Tracing: TracingConfig{
+			Exporter:    TracingNone,
+			SampleRatio: 1,
+			ParentBased: true,
+			ServiceName: "messenger-gateway",
+		},
+		AccessLog: AccessLogConfig{
+			Format:        "json",
+			Output:        "stdout",
+			SampleRate:    1,
+			SlowThreshold: time.Second,
+		},
+		API: APIConfig{
+			LegacyDeprecated: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+			Docs:             true,
+			DefaultPageSize:  50,
+			MaxPageSize:      100,
+		},
+		Compression: CompressionConfig{
+			Enabled:       true,
+			Encodings:     []string{"zstd", "br", "gzip"},
+			MinSize:       1024,
+			ContentTypes:  []string{"application/json", "text/*", "application/javascript"},
+			ExcludeRoutes: []string{"/notifications/longpoll"},
+		},
+		Audit: AuditConfig{
+			Sink:                 AuditRedis,
+			Stream:               "gateway:audit",
+			MaxLen:               1_000_000,
+			TokenInvalidInterval: time.Minute,
+			QueueSize:            1024,
+		},
+	}
+}
+
+func defaultGRPCUpstream(addr string) GRPCUpstreamConfig {
+	return GRPCUpstreamConfig{
+		Addr:            addr,
+		Discovery:       DiscoveryDNS,
+		RefreshInterval: 30 * time.Second,
+		Balancer:        "round_robin",
+		HealthCheck:     true,
+		Outlier: OutlierConfig{
+			ConsecutiveFailures: 5,
+			BaseEjectionTime:    30 * time.Second,
+			MaxEjectionPercent:  50,
+		},
+		Keepalive: KeepaliveConfig{Timeout: 20 * time.Second},
+		TLS:       ClientTLSConfig{ReloadInterval: 30 * time.Second},
+	}
+}
+
+// Load собирает конфигурацию по приоритету: значения по умолчанию, YAML-файл,
+// переменные окружения, флаги командной строки. Путь к файлу задаётся флагом -config
+// или переменной GATEWAY_CONFIG. Результат проверяется Validate.
+func Load(name string, args []string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("GATEWAY_CONFIG"), "path to YAML config file")
+	httpAddr := fs.String("http-addr", "", "HTTP listen address")
+	dialogsAddr := fs.String("dialogs-addr", "", "dialog service gRPC address")
+	usersAddr := fs.String("users-addr", "", "users service gRPC address")
+	notificationsURL := fs.String("notifications-url", "", "notifications service base URL")
+	redisAddr := fs.String("redis-addr", "", "Redis address")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return Config{}, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "http-addr":
+			cfg.HTTP.Addr = *httpAddr
+		case "dialogs-addr":
+			cfg.Upstreams.Dialogs.Addr = *dialogsAddr
+		case "users-addr":
+			cfg.Upstreams.Users.Addr = *usersAddr
+		case "notifications-url":
+			cfg.Upstreams.Notifications = *notificationsURL
+		case "redis-addr":
+			cfg.Redis.Addr = *redisAddr
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv переопределяет значения переменными GATEWAY_*. REDIS_ADDR и SECRETKEY
+// поддерживаются для совместимости с существующими окружениями.
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	str := func(dst *string, names ...string) {
+		for _, name := range names {
+			if v, ok := lookup(name); ok && v != "" {
+				*dst = v
+			}
+		}
+	}
+	secret := func(dst *Secret, names ...string) {
+		for _, name := range names {
+			if v, ok := lookup(name); ok && v != "" {
+				*dst = Secret(v)
+			}
+		}
+	}
+	list := func(dst *[]string, name string) {
+		if v, ok := lookup(name); ok && v != "" {
+			*dst = strings.Split(v, ",")
+		}
+	}
+	var errs []error
+	duration := func(dst *time.Duration, name string) {
+		if v, ok := lookup(name); ok && v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				return
+			}
+			*dst = d
+		}
+	}
+	integer := func(dst *int, name string) {
+		if v, ok := lookup(name); ok && v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				return
+			}
+			*dst = n
+		}
+	}
+	boolean := func(dst *bool, name string) {
+		if v, ok := lookup(name); ok && v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				return
+			}
+			*dst = b
+		}
+	}
+	float := func(dst *float64, name string) {
+		if v, ok := lookup(name); ok && v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				return
+			}
+			*dst = f
+		}
+	}
+
+	str(&c.HTTP.Addr, "GATEWAY_HTTP_ADDR")
+	duration(&c.HTTP.ReadTimeout, "GATEWAY_HTTP_READ_TIMEOUT")
+	duration(&c.HTTP.WriteTimeout, "GATEWAY_HTTP_WRITE_TIMEOUT")
+	duration(&c.HTTP.IdleTimeout, "GATEWAY_HTTP_IDLE_TIMEOUT")
+	str(&c.HTTP.TLS.CertFile, "GATEWAY_TLS_CERT_FILE")
+	str(&c.HTTP.TLS.KeyFile, "GATEWAY_TLS_KEY_FILE")
+	str(&c.Upstreams.Dialogs.Addr, "GATEWAY_DIALOGS_ADDR")
+	str(&c.Upstreams.Users.Addr, "GATEWAY_USERS_ADDR")
+	str(&c.Upstreams.Dialogs.Discovery, "GATEWAY_DIALOGS_DISCOVERY")
+	str(&c.Upstreams.Users.Discovery, "GATEWAY_USERS_DISCOVERY")
+	list(&c.Upstreams.Dialogs.Endpoints, "GATEWAY_DIALOGS_ENDPOINTS")
+	list(&c.Upstreams.Users.Endpoints, "GATEWAY_USERS_ENDPOINTS")
+	str(&c.Upstreams.Notifications, "GATEWAY_NOTIFICATIONS_URL")
+	str(&c.Redis.Mode, "GATEWAY_REDIS_MODE")
+	str(&c.Redis.Addr, "REDIS_ADDR", "GATEWAY_REDIS_ADDR")
+	list(&c.Redis.Addrs, "GATEWAY_REDIS_ADDRS")
+	str(&c.Redis.MasterName, "GATEWAY_REDIS_MASTER_NAME")
+	str(&c.Redis.Username, "GATEWAY_REDIS_USERNAME")
+	secret(&c.Redis.Password, "GATEWAY_REDIS_PASSWORD")
+	secret(&c.Redis.SentinelPassword, "GATEWAY_REDIS_SENTINEL_PASSWORD")
+	integer(&c.Redis.DB, "GATEWAY_REDIS_DB")
+	secret(&c.JWT.Secret, "SECRETKEY", "GATEWAY_JWT_SECRET")
+	duration(&c.Timeouts.Default, "GATEWAY_TIMEOUT_DEFAULT")
+	duration(&c.Shutdown.DrainDelay, "GATEWAY_SHUTDOWN_DRAIN_DELAY")
+	duration(&c.Shutdown.Timeout, "GATEWAY_SHUTDOWN_TIMEOUT")
+	duration(&c.Health.Timeout, "GATEWAY_HEALTH_TIMEOUT")
+	str(&c.Admin.Addr, "GATEWAY_ADMIN_ADDR")
+	secret(&c.Admin.Token, "GATEWAY_ADMIN_TOKEN")
+	str(&c.Log.Level, "GATEWAY_LOG_LEVEL")
+	str(&c.Tracing.Exporter, "GATEWAY_TRACING_EXPORTER")
+	str(&c.Tracing.Endpoint, "GATEWAY_TRACING_ENDPOINT")
+	str(&c.Tracing.File, "GATEWAY_TRACING_FILE")
+	float(&c.Tracing.SampleRatio, "GATEWAY_TRACING_SAMPLE_RATIO")
+	str(&c.AccessLog.Format, "GATEWAY_ACCESS_LOG_FORMAT")
+	str(&c.AccessLog.Output, "GATEWAY_ACCESS_LOG_OUTPUT")
+	float(&c.AccessLog.SampleRate, "GATEWAY_ACCESS_LOG_SAMPLE_RATE")
+	duration(&c.AccessLog.SlowThreshold, "GATEWAY_ACCESS_LOG_SLOW_THRESHOLD")
+	boolean(&c.API.Docs, "GATEWAY_API_DOCS")
+	boolean(&c.API.ValidateRequests, "GATEWAY_API_VALIDATE_REQUESTS")
+	boolean(&c.API.ValidateResponses, "GATEWAY_API_VALIDATE_RESPONSES")
+	integer(&c.API.DefaultPageSize, "GATEWAY_API_DEFAULT_PAGE_SIZE")
+	integer(&c.API.MaxPageSize, "GATEWAY_API_MAX_PAGE_SIZE")
+	secret(&c.API.CursorSecret, "GATEWAY_API_CURSOR_SECRET")
+	boolean(&c.Compression.Enabled, "GATEWAY_COMPRESSION_ENABLED")
+	list(&c.Compression.Encodings, "GATEWAY_COMPRESSION_ENCODINGS")
+	integer(&c.Compression.MinSize, "GATEWAY_COMPRESSION_MIN_SIZE")
+	str(&c.Audit.Sink, "GATEWAY_AUDIT_SINK")
+	str(&c.Audit.File, "GATEWAY_AUDIT_FILE")
+	str(&c.Audit.Stream, "GATEWAY_AUDIT_STREAM")
+	if v, ok := lookup("GATEWAY_ROUTE_TIMEOUTS"); ok && v != "" {
+		routes, err := parseRouteTimeouts(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("GATEWAY_ROUTE_TIMEOUTS: %w", err))
+		}
+		if c.Timeouts.Routes == nil {
+			c.Timeouts.Routes = make(map[string]time.Duration)
+		}
+		for path, d := range routes {
+			c.Timeouts.Routes[path] = d
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseRouteTimeouts разбирает строку вида "/dialog/send=3s,/notifications/longpoll=30s".
+func parseRouteTimeouts(s string) (map[string]time.Duration, error) {
+	routes := make(map[string]time.Duration)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		path, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid route timeout %q", item)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route timeout %q", item)
+		}
+		routes[path] = d
+	}
+	return routes, nil
+}
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки сразу.
+func (c Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.HTTP.Addr == "" {
+		add("http.addr is required")
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		add("log.level: %v", err)
+	}
+	if c.Admin.Addr != "" && c.Admin.Addr == c.HTTP.Addr {
+		add("admin.addr must differ from http.addr")
+	}
+	if c.HTTP.ReadTimeout <= 0 || c.HTTP.WriteTimeout <= 0 || c.HTTP.IdleTimeout <= 0 {
+		add("http timeouts must be positive")
+	}
+	if tlsCfg := c.HTTP.TLS; tlsCfg.Enabled {
+		if tlsCfg.CertFile == "" || tlsCfg.KeyFile == "" {
+			add("http.tls: cert_file and key_file are required")
+		}
+		if _, err := tlsutil.ParseVersion(tlsCfg.MinVersion); err != nil {
+			add("http.tls.min_version: %v", err)
+		}
+		if ids, err := tlsutil.ParseCipherSuites(tlsCfg.CipherSuites); err != nil {
+			add("http.tls.cipher_suites: %v", err)
+		} else if len(ids) > 0 && !slices.Contains(ids, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256) &&
+			!slices.Contains(ids, tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256) {
+			add("http.tls.cipher_suites must include an ECDHE AES_128_GCM_SHA256 suite required by HTTP/2")
+		}
+		if tlsCfg.ReloadInterval <= 0 {
+			add("http.tls.reload_interval must be positive")
+		}
+	}
+	if c.HTTP.H2C && c.HTTP.TLS.Enabled {
+		add("http.h2c cannot be combined with http.tls")
+	}
+	if c.HTTP.RedirectAddr != "" && !c.HTTP.TLS.Enabled {
+		add("http.redirect_addr requires http.tls")
+	}
+	errs = append(errs, c.Upstreams.Dialogs.validate("upstreams.dialogs")...)
+	errs = append(errs, c.Upstreams.Users.validate("upstreams.users")...)
+	if u, err := url.Parse(c.Upstreams.Notifications); err != nil || u.Scheme == "" || u.Host == "" {
+		add("upstreams.notifications must be an absolute URL")
+	}
+	errs = append(errs, c.Redis.validate()...)
+	errs = append(errs, c.Tracing.validate()...)
+	errs = append(errs, c.Audit.validate()...)
+	errs = append(errs, c.AccessLog.validate()...)
+	errs = append(errs, c.Compression.validate()...)
+	if c.API.LegacyDeprecated.IsZero() {
+		add("api.legacy_deprecated is required")
+	}
+	if !c.API.LegacySunset.IsZero() && !c.API.LegacySunset.After(c.API.LegacyDeprecated) {
+		add("api.legacy_sunset must be after api.legacy_deprecated")
+	}
+	if c.API.MaxPageSize < 1 || c.API.MaxPageSize > maxPageSizeLimit {
+		add("api.max_page_size must be between 1 and %d", maxPageSizeLimit)
+	}
+	if c.API.DefaultPageSize < 1 || c.API.DefaultPageSize > c.API.MaxPageSize {
+		add("api.default_page_size must be between 1 and api.max_page_size")
+	}
+	if c.JWT.Secret == "" {
+		add("jwt.secret is required (SECRETKEY)")
+	}
+	if c.Timeouts.Default <= 0 || c.Timeouts.Default >= c.HTTP.WriteTimeout {
+		add("timeouts.default must be positive and shorter than http.write_timeout")
+	}
+	for path, d := range c.Timeouts.Routes {
+		if !strings.HasPrefix(path, "/") {
+			add("timeouts.routes: %q must start with /", path)
+		}
+		if d <= 0 {
+			add("timeouts.routes[%s] must be positive", path)
+		}
+		if d >= c.HTTP.WriteTimeout {
+			add("timeouts.routes[%s] must be shorter than http.write_timeout", path)
+		}
+	}
+	if c.Limiter.MinLimit <= 0 || c.Limiter.MaxLimit < c.Limiter.MinLimit {
+		add("limiter: need 0 < min_limit <= max_limit")
+	}
+	if c.Limiter.InitialLimit < c.Limiter.MinLimit || c.Limiter.InitialLimit > c.Limiter.MaxLimit {
+		add("limiter.initial_limit must be within [min_limit, max_limit]")
+	}
+	if c.Limiter.BackoffRatio <= 0 || c.Limiter.BackoffRatio >= 1 {
+		add("limiter.backoff_ratio must be in (0, 1)")
+	}
+	if c.Health.Timeout <= 0 {
+		add("health.timeout must be positive")
+	}
+	for _, name := range c.Health.Critical {
+		if !slices.Contains(HealthDependencies, name) {
+			add("health.critical: unknown dependency %q", name)
+		}
+	}
+	if c.Shutdown.DrainDelay < 0 || c.Shutdown.Timeout <= 0 {
+		add("shutdown: drain_delay must not be negative and timeout must be positive")
+	}
+	return errors.Join(errs...)
+}
+
+// Redacted возвращает конфигурацию в YAML со скрытыми секретами.
+func (c Config) Redacted() ([]byte, error) {
+	return yaml.Marshal(c)
+}
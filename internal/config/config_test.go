@@ -0,0 +1,238 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeConfig(t, `
+http:
+  addr: ":9000"
+upstreams:
+  dialogs: "dialogs.file:9001"
+  users:
+    addr: "users.file:9000"
+jwt:
+  secret: "from-file"
+timeouts:
+  routes:
+    /dialog/send: 3s
+`)
+	t.Setenv("SECRETKEY", "")
+	t.Setenv("GATEWAY_USERS_ADDR", "users.env:9000")
+	t.Setenv("GATEWAY_DIALOGS_ADDR", "dialogs.env:9001")
+
+	cfg, err := Load("test", []string{"-config", path, "-dialogs-addr", "dialogs.flag:9001"})
+	require.NoError(t, err)
+
+	assert.Equal(t, ":9000", cfg.HTTP.Addr)
+	assert.Equal(t, "users.env:9000", cfg.Upstreams.Users.Addr)
+	assert.Equal(t, "dialogs.flag:9001", cfg.Upstreams.Dialogs.Addr)
+	assert.Equal(t, "from-file", cfg.JWT.Secret.Value())
+	assert.Equal(t, 3*time.Second, cfg.Timeouts.Routes["/dialog/send"])
+	// Значение по умолчанию сохраняется, если файл его не переопределяет.
+	assert.Equal(t, 35*time.Second, cfg.HTTP.WriteTimeout)
+}
+
+func TestLoad_UnknownField(t *testing.T) {
+	path := writeConfig(t, "http:\n  adr: \":9000\"\n")
+	t.Setenv("SECRETKEY", "secret")
+
+	_, err := Load("test", []string{"-config", path})
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.JWT.Secret = ""
+	cfg.Upstreams.Notifications = "notifications"
+	cfg.Limiter.BackoffRatio = 1.5
+	cfg.Timeouts.Routes["/notifications/longpoll"] = time.Minute
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, field := range []string{"jwt.secret", "upstreams.notifications", "limiter.backoff_ratio", "/notifications/longpoll"} {
+		assert.Contains(t, err.Error(), field)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "supersecret"
+	cfg.Redis.Password = "redispass"
+
+	out, err := cfg.Redacted()
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "supersecret")
+	assert.NotContains(t, string(out), "redispass")
+	assert.Equal(t, 2, strings.Count(string(out), redacted))
+}
+
+func TestValidate_UpstreamTLSFilesMustExist(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+	cfg.Upstreams.Dialogs.TLS.Enabled = true
+	cfg.Upstreams.Dialogs.TLS.CAFile = "/nonexistent/ca.pem"
+	cfg.Upstreams.Users.TLS.Enabled = true
+	cfg.Upstreams.Users.TLS.CertFile = "/nonexistent/client.crt"
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "upstreams.dialogs.tls")
+	assert.Contains(t, err.Error(), "cert_file and key_file must be set together")
+}
+
+func TestValidate_UpstreamTLSServerNameWithStaticDiscovery(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+	cfg.Upstreams.Dialogs.Discovery = DiscoveryStatic
+	cfg.Upstreams.Dialogs.Endpoints = []string{"10.0.0.1:9001", "10.0.0.2:9001"}
+	cfg.Upstreams.Dialogs.TLS.Enabled = true
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tls.server_name is required with static discovery")
+
+	cfg.Upstreams.Dialogs.TLS.ServerName = "dialogs.internal"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate_RedisModes(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+	cfg.Redis.Mode = RedisSentinel
+	cfg.Redis.Addrs = []string{"sentinel:26379"}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "master_name")
+
+	cfg.Redis.MasterName = "mymaster"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Redis.Mode = RedisCluster
+	cfg.Redis.DB = 1
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cluster mode")
+
+	cfg.Redis.Mode = "replica"
+	assert.ErrorContains(t, cfg.Validate(), "redis.mode")
+}
+
+func TestLoad_RedisAddrsFromEnv(t *testing.T) {
+	t.Setenv("SECRETKEY", "secret")
+	t.Setenv("GATEWAY_REDIS_MODE", "cluster")
+	t.Setenv("GATEWAY_REDIS_ADDRS", "redis-0:6379,redis-1:6379")
+
+	cfg, err := Load("test", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"redis-0:6379", "redis-1:6379"}, cfg.Redis.Addrs)
+}
+
+func TestLoad_APIValidationFromEnv(t *testing.T) {
+	t.Setenv("SECRETKEY", "secret")
+	t.Setenv("GATEWAY_API_VALIDATE_REQUESTS", "true")
+	t.Setenv("GATEWAY_API_DOCS", "false")
+
+	cfg, err := Load("test", nil)
+	require.NoError(t, err)
+	assert.True(t, cfg.API.ValidateRequests)
+	assert.False(t, cfg.API.ValidateResponses)
+	assert.False(t, cfg.API.Docs)
+
+	t.Setenv("GATEWAY_API_VALIDATE_RESPONSES", "sometimes")
+	_, err = Load("test", nil)
+	assert.ErrorContains(t, err, "GATEWAY_API_VALIDATE_RESPONSES")
+}
+
+func TestValidate_Compression(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+	cfg.Compression.Encodings = []string{"gzip", "deflate"}
+	cfg.Compression.MinSize = -1
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"deflate"`)
+	assert.Contains(t, err.Error(), "compression.min_size")
+
+	// Выключенное сжатие не проверяется
+	cfg.Compression.Enabled = false
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate_PageSize(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+	require.NoError(t, cfg.Validate())
+
+	cfg.API.DefaultPageSize = 200
+	assert.ErrorContains(t, cfg.Validate(), "api.default_page_size")
+
+	cfg.API.DefaultPageSize = 50
+	cfg.API.MaxPageSize = 5000
+	assert.ErrorContains(t, cfg.Validate(), "api.max_page_size")
+}
+
+func TestGRPCUpstreamConfig_Target(t *testing.T) {
+	u := defaultGRPCUpstream("dialog_service:9001")
+	assert.Equal(t, "dns:///dialog_service:9001", u.Target())
+	assert.Empty(t, u.validate("dialogs"))
+
+	u.Discovery = DiscoveryStatic
+	u.Endpoints = []string{"10.0.0.1:9001", "10.0.0.2:9001"}
+	assert.Equal(t, "static:///10.0.0.1:9001,10.0.0.2:9001", u.Target())
+
+	u.Discovery = DiscoveryFile
+	u.File = "relative.txt"
+	assert.NotEmpty(t, u.validate("dialogs"))
+	u.File = "/etc/gateway/dialogs.txt"
+	assert.Equal(t, "file:///etc/gateway/dialogs.txt", u.Target())
+
+	u.Discovery = DiscoveryDNS
+	u.Balancer = "random"
+	assert.NotEmpty(t, u.validate("dialogs"))
+}
+
+func TestValidate_Tracing(t *testing.T) {
+	t.Setenv("SECRETKEY", "secret")
+	t.Setenv("GATEWAY_TRACING_EXPORTER", "file")
+	t.Setenv("GATEWAY_TRACING_SAMPLE_RATIO", "1.5")
+
+	_, err := Load("test", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tracing.file")
+	assert.Contains(t, err.Error(), "tracing.sample_ratio")
+
+	t.Setenv("GATEWAY_TRACING_FILE", "/tmp/spans.json")
+	t.Setenv("GATEWAY_TRACING_SAMPLE_RATIO", "0.25")
+	cfg, err := Load("test", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+}
+
+func TestValidate_AccessLog(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+	cfg.AccessLog.Format = "apache"
+	cfg.AccessLog.Routes = map[string]float64{"/dialog/send": 2}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "access_log.format")
+	assert.Contains(t, err.Error(), "access_log.routes[/dialog/send]")
+}
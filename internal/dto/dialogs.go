@@ -0,0 +1,103 @@
+package dto
+
+import (
+	dapi "github.com/GalahadKingsman/messenger_dialog/pkg/messenger_dialog_api"
+)
+
+type Dialog struct {
+	DialogID    ID     `json:"dialog_id"`
+	PeerID      ID     `json:"peer_id"`
+	PeerLogin   string `json:"peer_login"`
+	LastMessage string `json:"last_message"`
+}
+
+type DialogList struct {
+	Dialogs []Dialog `json:"dialogs"`
+	Links   Links    `json:"links"`
+}
+
+type Message struct {
+	ID        ID     `json:"id"`
+	UserID    ID     `json:"user_id"`
+	Text      string `json:"text"`
+	Timestamp Time   `json:"timestamp"`
+}
+
+type MessageList struct {
+	Messages []Message `json:"messages"`
+	Links    Links     `json:"links"`
+}
+
+// Links — ссылки на соседние страницы списка; отсутствующая страница не выводится.
+type Links struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+type CreateDialogRequest struct {
+	PeerID     ID     `json:"peer_id"`
+	DialogName string `json:"dialog_name"`
+}
+
+type CreateDialogResponse struct {
+	DialogID   ID     `json:"dialog_id"`
+	DialogName string `json:"dialog_name"`
+	Success    bool   `json:"success"`
+}
+
+// SendMessageRequest — тело POST /v1/dialogs/{id}/messages; диалог задаётся в пути.
+type SendMessageRequest struct {
+	Text string `json:"text"`
+}
+
+// LegacySendMessageRequest — тело устаревшего POST /dialog/send.
+type LegacySendMessageRequest struct {
+	DialogID ID     `json:"dialog_id"`
+	Text     string `json:"text"`
+}
+
+type SendMessageResponse struct {
+	MessageID ID   `json:"message_id"`
+	Timestamp Time `json:"timestamp"`
+}
+
+func DialogsFromProto(resp *dapi.GetUserDialogsResponse) DialogList {
+	out := DialogList{Dialogs: make([]Dialog, 0, len(resp.Dialogs))}
+	for _, d := range resp.Dialogs {
+		out.Dialogs = append(out.Dialogs, Dialog{
+			DialogID:    ID(d.DialogId),
+			PeerID:      ID(d.PeerId),
+			PeerLogin:   d.PeerLogin,
+			LastMessage: d.LastMessage,
+		})
+	}
+	return out
+}
+
+func MessagesFromProto(resp *dapi.GetDialogMessagesResponse) MessageList {
+	out := MessageList{Messages: make([]Message, 0, len(resp.Messages))}
+	for _, m := range resp.Messages {
+		out.Messages = append(out.Messages, Message{
+			ID:        ID(m.Id),
+			UserID:    ID(m.UserId),
+			Text:      m.Text,
+			Timestamp: TimeFromProto(m.Timestamp),
+		})
+	}
+	return out
+}
+
+func CreateDialogFromProto(resp *dapi.CreateDialogResponse) CreateDialogResponse {
+	return CreateDialogResponse{
+		DialogID:   ID(resp.DialogId),
+		DialogName: resp.DialogName,
+		Success:    resp.Success,
+	}
+}
+
+func SendMessageFromProto(resp *dapi.SendMessageResponse) SendMessageResponse {
+	return SendMessageResponse{
+		MessageID: ID(resp.MessageId),
+		Timestamp: TimeFromProto(resp.Timestamp),
+	}
+}
@@ -0,0 +1,139 @@
+package dto
+
+import (
+	"time"
+)
+
+// Маршруты без /v1 (/dialog/*, /users/get, /users/login) до отключения отдают прежний формат:
+// идентификаторы — числами, время — RFC 3339 с точностью до секунды. Типы ниже нужны только им;
+// ответы /v1 собираются из основных DTO и переводятся сюда методом Legacy.
+
+// LegacyTime — метка времени в прежнем формате time.RFC3339, всегда в UTC; нулевое значение — null.
+type LegacyTime struct {
+	time.Time
+}
+
+func (t LegacyTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + t.UTC().Format(time.RFC3339) + `"`), nil
+}
+
+type LegacyDialog struct {
+	DialogID    int64  `json:"dialog_id"`
+	PeerID      int64  `json:"peer_id"`
+	PeerLogin   string `json:"peer_login"`
+	LastMessage string `json:"last_message"`
+}
+
+// LegacyDialogList и LegacyMessageList не содержат ссылок на страницы: старые маршруты
+// передают их только в заголовке Link.
+type LegacyDialogList struct {
+	Dialogs []LegacyDialog `json:"dialogs"`
+}
+
+type LegacyMessage struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	Text      string     `json:"text"`
+	Timestamp LegacyTime `json:"timestamp"`
+}
+
+type LegacyMessageList struct {
+	Messages []LegacyMessage `json:"messages"`
+}
+
+type LegacyCreateDialogResponse struct {
+	DialogID   int64  `json:"dialog_id"`
+	DialogName string `json:"dialog_name"`
+	Success    bool   `json:"success"`
+}
+
+type LegacySendMessageResponse struct {
+	MessageID int64      `json:"message_id"`
+	Timestamp LegacyTime `json:"timestamp"`
+}
+
+type LegacyUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+}
+
+type LegacyUserList struct {
+	Users []LegacyUser `json:"users"`
+}
+
+type LegacyLoginResponse struct {
+	Message string `json:"message"`
+	UserID  int64  `json:"user_id"`
+	Token   string `json:"token"`
+}
+
+func (l DialogList) Legacy() LegacyDialogList {
+	out := LegacyDialogList{Dialogs: make([]LegacyDialog, 0, len(l.Dialogs))}
+	for _, d := range l.Dialogs {
+		out.Dialogs = append(out.Dialogs, LegacyDialog{
+			DialogID:    int64(d.DialogID),
+			PeerID:      int64(d.PeerID),
+			PeerLogin:   d.PeerLogin,
+			LastMessage: d.LastMessage,
+		})
+	}
+	return out
+}
+
+func (l MessageList) Legacy() LegacyMessageList {
+	out := LegacyMessageList{Messages: make([]LegacyMessage, 0, len(l.Messages))}
+	for _, m := range l.Messages {
+		out.Messages = append(out.Messages, LegacyMessage{
+			ID:        int64(m.ID),
+			UserID:    int64(m.UserID),
+			Text:      m.Text,
+			Timestamp: LegacyTime{m.Timestamp.Time},
+		})
+	}
+	return out
+}
+
+func (r CreateDialogResponse) Legacy() LegacyCreateDialogResponse {
+	return LegacyCreateDialogResponse{
+		DialogID:   int64(r.DialogID),
+		DialogName: r.DialogName,
+		Success:    r.Success,
+	}
+}
+
+func (r SendMessageResponse) Legacy() LegacySendMessageResponse {
+	return LegacySendMessageResponse{
+		MessageID: int64(r.MessageID),
+		Timestamp: LegacyTime{r.Timestamp.Time},
+	}
+}
+
+func (l UserList) Legacy() LegacyUserList {
+	out := LegacyUserList{Users: make([]LegacyUser, 0, len(l.Users))}
+	for _, u := range l.Users {
+		out.Users = append(out.Users, LegacyUser{
+			ID:        int64(u.ID),
+			Login:     u.Login,
+			FirstName: u.FirstName,
+			LastName:  u.LastName,
+			Email:     u.Email,
+			Phone:     u.Phone,
+		})
+	}
+	return out
+}
+
+func (r LoginResponse) Legacy() LegacyLoginResponse {
+	return LegacyLoginResponse{
+		Message: r.Message,
+		UserID:  int64(r.UserID),
+		Token:   r.Token,
+	}
+}
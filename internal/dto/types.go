@@ -0,0 +1,92 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ID — идентификатор в ответах API. Передаётся строкой, как int64 в protojson: JavaScript
+// теряет точность на числах больше 2^53. Во входящих запросах принимается и число.
+type ID int64
+
+func (id ID) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.FormatInt(int64(id), 10) + `"`), nil
+}
+
+func (id *ID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("id must be an integer, got %s", data)
+	}
+	*id = ID(n)
+	return nil
+}
+
+// Int32 возвращает идентификатор как int32 — в нём идентификаторы передаёт gRPC-API диалогов.
+// ok = false, если значение в int32 не помещается: усекать его нельзя, иначе запрос уйдёт
+// к чужому диалогу или пользователю.
+func (id ID) Int32() (v int32, ok bool) {
+	if id < math.MinInt32 || id > math.MaxInt32 {
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func (id ID) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// TimeLayout — RFC 3339 в UTC с миллисекундами; одинаков для всех меток времени API.
+const TimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// Time — метка времени в формате TimeLayout. Нулевое значение означает, что upstream
+// время не передал, и сериализуется как null.
+type Time struct {
+	time.Time
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + t.UTC().Format(TimeLayout) + `"`), nil
+}
+
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// TimeFromProto переводит метку времени protobuf; nil становится нулевым Time, а не началом
+// эпохи Unix, которое выглядело бы настоящим временем.
+func TimeFromProto(ts *timestamppb.Timestamp) Time {
+	if ts == nil {
+		return Time{}
+	}
+	return Time{ts.AsTime()}
+}
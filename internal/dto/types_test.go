@@ -0,0 +1,84 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	dapi "github.com/GalahadKingsman/messenger_dialog/pkg/messenger_dialog_api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestID_JSON(t *testing.T) {
+	out, err := json.Marshal(struct {
+		ID ID `json:"id"`
+	}{ID: 9007199254740993})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"9007199254740993"}`, string(out))
+
+	// Во входящих телах принимаются и строка, и число
+	for _, body := range []string{`{"peer_id":"42"}`, `{"peer_id":42}`} {
+		var req CreateDialogRequest
+		require.NoError(t, json.Unmarshal([]byte(body), &req), body)
+		assert.Equal(t, ID(42), req.PeerID, body)
+	}
+
+	var req CreateDialogRequest
+	assert.Error(t, json.Unmarshal([]byte(`{"peer_id":"abc"}`), &req))
+	assert.Error(t, json.Unmarshal([]byte(`{"peer_id":1.5}`), &req))
+}
+
+func TestID_Int32(t *testing.T) {
+	v, ok := ID(2147483647).Int32()
+	assert.True(t, ok)
+	assert.Equal(t, int32(2147483647), v)
+
+	for _, id := range []ID{2147483648, 4294967301, -2147483649} {
+		_, ok := id.Int32()
+		assert.False(t, ok, id)
+	}
+}
+
+func TestTime_JSON(t *testing.T) {
+	ts := time.Date(2025, 7, 1, 15, 4, 5, 0, time.FixedZone("MSK", 3*60*60))
+	out, err := json.Marshal(Time{ts})
+	require.NoError(t, err)
+	assert.Equal(t, `"2025-07-01T12:04:05.000Z"`, string(out))
+
+	var parsed Time
+	require.NoError(t, json.Unmarshal(out, &parsed))
+	assert.True(t, ts.Equal(parsed.Time))
+}
+
+func TestTimeFromProto_Nil(t *testing.T) {
+	assert.True(t, TimeFromProto(nil).IsZero())
+
+	resp := &dapi.SendMessageResponse{MessageId: 7}
+	out, err := json.Marshal(SendMessageFromProto(resp))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"message_id":"7","timestamp":null}`, string(out))
+
+	out, err = json.Marshal(SendMessageFromProto(resp).Legacy())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"message_id":7,"timestamp":null}`, string(out))
+
+	var parsed Time
+	require.NoError(t, json.Unmarshal([]byte("null"), &parsed))
+	assert.True(t, parsed.IsZero())
+}
+
+func TestMessagesFromProto(t *testing.T) {
+	list := MessagesFromProto(&dapi.GetDialogMessagesResponse{
+		Messages: []*dapi.Message{{Id: 7, UserId: 1, Text: "hi", Timestamp: timestamppb.New(time.UnixMilli(1751371445123))}},
+	})
+	out, err := json.Marshal(list)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"messages":[{"id":"7","user_id":"1","text":"hi","timestamp":"2025-07-01T12:04:05.123Z"}],"links":{}}`, string(out))
+
+	// Пустой ответ — пустой массив, а не null
+	out, err = json.Marshal(MessagesFromProto(&dapi.GetDialogMessagesResponse{}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"messages":[],"links":{}}`, string(out))
+}
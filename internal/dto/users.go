@@ -0,0 +1,82 @@
+package dto
+
+import (
+	uapi "github.com/GalahadKingsman/messenger_users/pkg/messenger_users_api"
+)
+
+type User struct {
+	ID        ID     `json:"id"`
+	Login     string `json:"login"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+}
+
+type UserList struct {
+	Users []User `json:"users"`
+}
+
+type CreateUserRequest struct {
+	Login     string `json:"login"`
+	Password  string `json:"password"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+}
+
+// CreateUserResponse повторяет ответ users-сервиса: в success приходит его результат.
+type CreateUserResponse struct {
+	Success string `json:"success"`
+}
+
+type LoginRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type LoginResponse struct {
+	Message string `json:"message"`
+	UserID  ID     `json:"user_id"`
+	Token   string `json:"token"`
+}
+
+// UsersFromProto переводит найденных пользователей; пустой ответ даёт пустой список.
+func UsersFromProto(resp *uapi.GetUserResponse) UserList {
+	out := UserList{Users: make([]User, 0, len(resp.Users))}
+	for _, u := range resp.Users {
+		out.Users = append(out.Users, User{
+			ID:        ID(u.Id),
+			Login:     u.Login,
+			FirstName: u.FirstName,
+			LastName:  u.LastName,
+			Email:     u.Email,
+			Phone:     u.Phone,
+		})
+	}
+	return out
+}
+
+func (r CreateUserRequest) Proto() *uapi.CreateRequest {
+	return &uapi.CreateRequest{
+		Login:     r.Login,
+		Password:  r.Password,
+		FirstName: r.FirstName,
+		LastName:  r.LastName,
+		Email:     r.Email,
+		Phone:     r.Phone,
+	}
+}
+
+func CreateUserFromProto(resp *uapi.CreateResponse) CreateUserResponse {
+	return CreateUserResponse{Success: resp.Success}
+}
+
+func LoginFromProto(resp *uapi.LoginResponse) LoginResponse {
+	return LoginResponse{
+		Message: resp.Message,
+		UserID:  ID(resp.UserId),
+		Token:   resp.Token,
+	}
+}
@@ -4,240 +4,355 @@ import (
 	"context"
 	"encoding/json"
 	dapi "github.com/GalahadKingsman/messenger_dialog/pkg/messenger_dialog_api"
-	"log"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"messenger_frontend/internal/apierror"
+	"messenger_frontend/internal/dto"
+	"messenger_frontend/internal/metrics"
 	"messenger_frontend/internal/middleware"
+	"messenger_frontend/internal/pagination"
+	"messenger_frontend/internal/router"
 	"net/http"
 	"strconv"
-	"time"
 )
 
 type DialogHandlerService struct {
 	dialogServiceClient dapi.DialogServiceClient
+	cache               *staleCache
+	// Pages разбирает параметры страниц списков и подписывает курсоры.
+	Pages *pagination.Paginator
+	// Shutdown отменяется при остановке шлюза и прерывает фоновое обновление кэша.
+	// nil — обновление не прерывается.
+	Shutdown context.Context
 }
 
-func NewDialogHandlerService(client dapi.DialogServiceClient) *DialogHandlerService {
-	return &DialogHandlerService{dialogServiceClient: client}
+// NewDialogHandlerService создаёт обработчики диалогов. Если redisClient не nil, списки диалогов
+// и сообщений кэшируются и отдаются из кэша при недоступности dialog-сервиса.
+func NewDialogHandlerService(client dapi.DialogServiceClient, redisClient redis.UniversalClient) *DialogHandlerService {
+	return &DialogHandlerService{
+		dialogServiceClient: client,
+		cache:               newStaleCache(redisClient),
+		Pages:               pagination.New(nil, pagination.DefaultLimit, pagination.MaxLimit),
+	}
+}
+
+func (d *DialogHandlerService) RegisterHandlers(mux router.Mux) {
+	mux.HandleFunc("GET /v1/dialogs", d.getUserDialogs(false))
+	mux.HandleFunc("POST /v1/dialogs", d.createDialog(http.StatusCreated, false))
+	mux.HandleFunc("GET /v1/dialogs/{id}/messages", d.getDialogMessages(false))
+	mux.HandleFunc("POST /v1/dialogs/{id}/messages", d.sendMessage(http.StatusCreated, false))
+}
+
+// RegisterLegacyHandlers регистрирует маршруты до /v1 как устаревшие синонимы. Они отвечают
+// в прежнем формате (dto.Legacy*), чтобы старые клиенты работали до даты отключения.
+func (d *DialogHandlerService) RegisterLegacyHandlers(mux router.Mux, dep middleware.Deprecation) {
+	mux.Handle("POST /dialog/create", middleware.DeprecatedMiddleware(dep, "/v1/dialogs", d.CreateDialogHandler()))
+	mux.Handle("POST /dialog/send", middleware.DeprecatedMiddleware(dep, "", d.SendMessageHandler()))
+	mux.Handle("GET /dialog/messages", middleware.DeprecatedMiddleware(dep, "", d.GetDialogMessagesHandler()))
+	mux.Handle("GET /dialog/user", middleware.DeprecatedMiddleware(dep, "/v1/dialogs", d.GetUserDialogsHandler()))
 }
 
-func (d *DialogHandlerService) RegisterHandlers(mux *http.ServeMux) {
-	mux.HandleFunc("/dialog/create", d.CreateDialogHandler())
-	mux.HandleFunc("/dialog/send", d.SendMessageHandler())
-	mux.HandleFunc("/dialog/messages", d.GetDialogMessagesHandler())
-	mux.HandleFunc("/dialog/user", d.GetUserDialogsHandler())
+// dialogIDParam берёт идентификатор диалога из пути /v1/dialogs/{id}/..., а для старых маршрутов — из query.
+func dialogIDParam(r *http.Request) string {
+	if id := r.PathValue("id"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("dialog_id")
 }
 
+// CreateDialogHandler обслуживает устаревший POST /dialog/create.
 func (d *DialogHandlerService) CreateDialogHandler() http.HandlerFunc {
+	return d.createDialog(http.StatusOK, true)
+}
+
+// createDialog отвечает кодом status: /v1 возвращает 201, старый маршрут — 200.
+// legacy выбирает прежний формат ответа.
+func (d *DialogHandlerService) createDialog(status int, legacy bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userIDVal := r.Context().Value(middleware.UserIDKey)
 		userIDStr, ok := userIDVal.(string)
 		if !ok {
-			http.Error(w, "user ID missing", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.Unauthorized(apierror.CodeUnauthenticated))
 			return
 		}
 		userID, err := strconv.Atoi(userIDStr)
 		if err != nil {
-			http.Error(w, "invalid user ID", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.Unauthorized(apierror.CodeUnauthenticated))
 			return
 		}
 
-		type RequestBody struct {
-			PeerID     int32  `json:"peer_id"`
-			DialogName string `json:"dialog_name"`
+		var reqBody dto.CreateDialogRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			apierror.Write(w, r, apierror.BadRequest(apierror.CodeInvalidBody))
+			return
 		}
 
-		var reqBody RequestBody
-		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
-			http.Error(w, `{"error":"неправильный формат запроса"}`, http.StatusBadRequest)
+		peerID, ok := reqBody.PeerID.Int32()
+		if !ok {
+			e := apierror.BadRequest(apierror.CodeInvalidArgument)
+			e.Details = []apierror.FieldViolation{{Field: "peer_id", Description: "out of range"}}
+			apierror.Write(w, r, e)
 			return
 		}
 
 		grpcReq := &dapi.CreateDialogRequest{
 			UserId:     int32(userID),
-			PeerId:     reqBody.PeerID,
+			PeerId:     peerID,
 			DialogName: reqBody.DialogName,
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		ctx, cancel := upstreamContext(r)
 		defer cancel()
 		resp, err := d.dialogServiceClient.CreateDialog(ctx, grpcReq)
 		if err != nil {
-			log.Printf("CreateDialog error: %v", err)
-			http.Error(w, `{"error":"ошибка при создании диалога"}`, http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "CreateDialog failed", "upstream", "dialogs", "error", err)
+			writeUpstreamError(w, r, ctx, err, apierror.MsgCreateDialogFailed)
 			return
 		}
 
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"dialog_id":   resp.DialogId,
-			"dialog_name": resp.DialogName,
-			"success":     resp.Success,
-		})
+		if resp.Success {
+			metrics.DialogsCreated.Inc()
+		}
+		out := dto.CreateDialogFromProto(resp)
+		if legacy {
+			writeJSON(w, status, out.Legacy())
+			return
+		}
+		writeJSON(w, status, out)
 	}
 }
 
+// SendMessageHandler обслуживает устаревший POST /dialog/send.
 func (d *DialogHandlerService) SendMessageHandler() http.HandlerFunc {
+	return d.sendMessage(http.StatusOK, true)
+}
+
+// sendMessage берёт диалог из пути /v1/dialogs/{id}/messages, а на старом маршруте — из тела.
+func (d *DialogHandlerService) sendMessage(status int, legacy bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userIDVal := r.Context().Value(middleware.UserIDKey)
 		userIDStr, ok := userIDVal.(string)
 		if !ok {
-			http.Error(w, "user ID missing", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.Unauthorized(apierror.CodeUnauthenticated))
 			return
 		}
 		userID, err := strconv.Atoi(userIDStr)
 		if err != nil {
-			http.Error(w, "invalid user ID", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.Unauthorized(apierror.CodeUnauthenticated))
 			return
 		}
 
-		type RequestBody struct {
-			DialogID int32  `json:"dialog_id"`
-			Text     string `json:"text"`
-		}
-
-		var reqBody RequestBody
+		// Тело старого маршрута — надмножество тела /v1, поэтому хватает одного типа
+		var reqBody dto.LegacySendMessageRequest
 		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
-			http.Error(w, `{"error":"неправильный формат запроса"}`, http.StatusBadRequest)
+			apierror.Write(w, r, apierror.BadRequest(apierror.CodeInvalidBody))
 			return
 		}
 
+		if id := r.PathValue("id"); id != "" {
+			dialogID, err := strconv.ParseInt(id, 10, 32)
+			if err != nil {
+				apierror.Write(w, r, apierror.BadRequest(apierror.CodeDialogIDInvalid))
+				return
+			}
+			reqBody.DialogID = dto.ID(dialogID)
+		}
 		if reqBody.DialogID == 0 || reqBody.Text == "" {
-			http.Error(w, `{"error":"dialog_id и text обязательны"}`, http.StatusBadRequest)
+			apierror.Write(w, r, apierror.BadRequest(apierror.CodeMessageFieldsRequired))
+			return
+		}
+		dialogID, ok := reqBody.DialogID.Int32()
+		if !ok {
+			apierror.Write(w, r, apierror.BadRequest(apierror.CodeDialogIDInvalid))
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ctx, cancel := upstreamContext(r)
 		defer cancel()
 		grpcReq := &dapi.SendMessageRequest{
-			DialogId: reqBody.DialogID,
+			DialogId: dialogID,
 			UserId:   int32(userID),
 			Text:     reqBody.Text,
 		}
 		resp, err := d.dialogServiceClient.SendMessage(ctx, grpcReq)
 		if err != nil {
-			log.Printf("SendMessage error: %v", err)
-			http.Error(w, `{"error":"не удалось отправить сообщение"}`, http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "SendMessage failed", "upstream", "dialogs", "error", err)
+			writeUpstreamError(w, r, ctx, err, apierror.MsgSendMessageFailed)
 			return
 		}
 
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message_id": resp.MessageId,
-			"timestamp":  resp.Timestamp.AsTime().Format(time.RFC3339),
-		})
+		metrics.MessagesSent.Inc()
+		out := dto.SendMessageFromProto(resp)
+		if legacy {
+			writeJSON(w, status, out.Legacy())
+			return
+		}
+		writeJSON(w, status, out)
 	}
 }
 
+// GetUserDialogsHandler обслуживает устаревший GET /dialog/user.
 func (d *DialogHandlerService) GetUserDialogsHandler() http.HandlerFunc {
+	return d.getUserDialogs(true)
+}
+
+func (d *DialogHandlerService) getUserDialogs(legacy bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userIDVal := r.Context().Value(middleware.UserIDKey)
 		userIDStr, ok := userIDVal.(string)
 		if !ok {
-			http.Error(w, "user ID missing", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.Unauthorized(apierror.CodeUnauthenticated))
 			return
 		}
 		userID, err := strconv.Atoi(userIDStr)
 		if err != nil {
-			http.Error(w, "invalid user ID", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.Unauthorized(apierror.CodeUnauthenticated))
 			return
 		}
 
-		query := r.URL.Query()
-		var limitPtr, offsetPtr *int32
-
-		if limitStr := query.Get("limit"); limitStr != "" {
-			limitVal, err := strconv.Atoi(limitStr)
-			if err == nil && limitVal > 0 {
-				val := int32(limitVal)
-				limitPtr = &val
-			}
-		}
-		if offsetStr := query.Get("offset"); offsetStr != "" {
-			offsetVal, err := strconv.Atoi(offsetStr)
-			if err == nil && offsetVal >= 0 {
-				val := int32(offsetVal)
-				offsetPtr = &val
-			}
+		page, err := d.Pages.Parse(r.URL.Query(), "dialogs:"+userIDStr)
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+		// fetch может выполняться в фоне после ответа клиенту, поэтому не обращается к r
+		reqURL := *r.URL
+		// Лишний элемент показывает, есть ли следующая страница
 		grpcReq := &dapi.GetUserDialogsRequest{
 			UserId: int32(userID),
-			Limit:  limitPtr,
-			Offset: offsetPtr,
-		}
-		resp, err := d.dialogServiceClient.GetUserDialogs(ctx, grpcReq)
-		if err != nil {
-			log.Printf("GetUserDialogs error: %v", err)
-			http.Error(w, `{"error":"не удалось получить список диалогов"}`, http.StatusInternalServerError)
-			return
+			Limit:  ptr(page.Limit + 1),
+			Offset: ptr(page.Offset),
 		}
-
-		dialogs := make([]map[string]interface{}, 0, len(resp.Dialogs))
-		for _, d := range resp.Dialogs {
-			dialogs = append(dialogs, map[string]interface{}{
-				"dialog_id":    d.DialogId,
-				"peer_id":      d.PeerId,
-				"peer_login":   d.PeerLogin,
-				"last_message": d.LastMessage,
-			})
+		fetch := func(ctx context.Context) (listPage, error) {
+			resp, err := d.dialogServiceClient.GetUserDialogs(ctx, grpcReq)
+			if err != nil {
+				return listPage{}, err
+			}
+			list := dto.DialogsFromProto(resp)
+			hasNext := len(list.Dialogs) > int(page.Limit)
+			if hasNext {
+				list.Dialogs = list.Dialogs[:page.Limit]
+			}
+			list.Links = d.Pages.Links(&reqURL, page, hasNext)
+			if legacy {
+				return marshalPage(list.Legacy(), list.Links)
+			}
+			return marshalPage(list, list.Links)
 		}
 
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"dialogs": dialogs,
-		})
+		d.serveWithStaleFallback(w, r, dialogsCacheKey(r.URL.Path, userID, page), fetch,
+			"GetUserDialogs", apierror.MsgGetDialogsFailed)
 	}
 }
 
+// GetDialogMessagesHandler обслуживает устаревший GET /dialog/messages.
 func (d *DialogHandlerService) GetDialogMessagesHandler() http.HandlerFunc {
+	return d.getDialogMessages(true)
+}
+
+func (d *DialogHandlerService) getDialogMessages(legacy bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		query := r.URL.Query()
-		dialogIDStr := query.Get("dialog_id")
+		dialogIDStr := dialogIDParam(r)
 		if dialogIDStr == "" {
-			http.Error(w, `{"error":"dialog_id обязателен"}`, http.StatusBadRequest)
+			apierror.Write(w, r, apierror.BadRequest(apierror.CodeDialogIDRequired))
 			return
 		}
-		dialogID, err := strconv.Atoi(dialogIDStr)
+		dialogID, err := strconv.ParseInt(dialogIDStr, 10, 32)
 		if err != nil {
-			http.Error(w, `{"error":"dialog_id должен быть числом"}`, http.StatusBadRequest)
+			apierror.Write(w, r, apierror.BadRequest(apierror.CodeDialogIDInvalid))
 			return
 		}
-		var limitPtr, offsetPtr *int32
-		if limitStr := query.Get("limit"); limitStr != "" {
-			limitVal, err := strconv.Atoi(limitStr)
-			if err == nil && limitVal > 0 {
-				val := int32(limitVal)
-				limitPtr = &val
-			}
-		}
-		if offsetStr := query.Get("offset"); offsetStr != "" {
-			offsetVal, err := strconv.Atoi(offsetStr)
-			if err == nil && offsetVal >= 0 {
-				val := int32(offsetVal)
-				offsetPtr = &val
-			}
+		userIDStr, _ := r.Context().Value(middleware.UserIDKey).(string)
+		page, err := d.Pages.Parse(query, "messages:"+userIDStr+":"+strconv.FormatInt(dialogID, 10))
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
 		}
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+
+		reqURL := *r.URL
 		grpcReq := &dapi.GetDialogMessagesRequest{
 			DialogId: int32(dialogID),
-			Limit:    limitPtr,
-			Offset:   offsetPtr,
+			Limit:    ptr(page.Limit + 1),
+			Offset:   ptr(page.Offset),
 		}
-		resp, err := d.dialogServiceClient.GetDialogMessages(ctx, grpcReq)
-		if err != nil {
-			http.Error(w, `{"error":"не удалось получить сообщения"}`, http.StatusInternalServerError)
-			return
+		fetch := func(ctx context.Context) (listPage, error) {
+			resp, err := d.dialogServiceClient.GetDialogMessages(ctx, grpcReq)
+			if err != nil {
+				return listPage{}, err
+			}
+			list := dto.MessagesFromProto(resp)
+			hasNext := len(list.Messages) > int(page.Limit)
+			if hasNext {
+				list.Messages = list.Messages[:page.Limit]
+			}
+			list.Links = d.Pages.Links(&reqURL, page, hasNext)
+			if legacy {
+				return marshalPage(list.Legacy(), list.Links)
+			}
+			return marshalPage(list, list.Links)
 		}
-		messages := make([]map[string]interface{}, 0, len(resp.Messages))
-		for _, msg := range resp.Messages {
-			messages = append(messages, map[string]interface{}{
-				"id":        msg.Id,
-				"user_id":   msg.UserId,
-				"text":      msg.Text,
-				"timestamp": msg.Timestamp.AsTime().Format(time.RFC3339),
-			})
+
+		// Без пользователя в контексте кэш не используется: ответы кэшируются только per-user
+		var cacheKey string
+		if userIDStr != "" {
+			cacheKey = messagesCacheKey(r.URL.Path, userIDStr, int(dialogID), page)
+		}
+		d.serveWithStaleFallback(w, r, cacheKey, fetch,
+			"GetDialogMessages", apierror.MsgGetMessagesFailed)
+	}
+}
+
+// serveWithStaleFallback выполняет fetch и кэширует результат; если upstream недоступен или
+// перегружен, отдаёт последний успешный ответ из кэша, если он есть. Ошибки запроса (4xx)
+// и отмена запроса клиентом кэшем не маскируются.
+func (d *DialogHandlerService) serveWithStaleFallback(w http.ResponseWriter, r *http.Request, cacheKey string, fetch fetchFunc, method, errKey string) {
+	ctx, cancel := upstreamContext(r)
+	defer cancel()
+
+	page, err := fetch(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), method+" failed", "upstream", "dialogs", "error", err)
+		if r.Context().Err() == nil && staleEligible(ctx, err) && d.cache.serveStale(w, r, d.shutdown(), cacheKey, fetch) {
+			return
 		}
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"messages": messages,
-		})
+		writeUpstreamError(w, r, ctx, err, errKey)
+		return
+	}
+	if cacheKey != "" {
+		d.cache.store(ctx, cacheKey, page)
 	}
+	writePage(w, page)
+}
+
+func (d *DialogHandlerService) shutdown() context.Context {
+	if d.Shutdown == nil {
+		return context.Background()
+	}
+	return d.Shutdown
+}
+
+func marshalPage(body any, links dto.Links) (listPage, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return listPage{}, err
+	}
+	return listPage{Body: b, Links: links}, nil
+}
+
+// writePage отдаёт страницу списка и повторяет ссылки на соседние страницы в заголовке Link.
+// Свежий и устаревший ответы пишутся одинаково, чтобы у одной записи кэша были одно тело
+// и один ETag, каким бы путём она ни попала к клиенту.
+func writePage(w http.ResponseWriter, page listPage) {
+	w.Header().Set("Content-Type", "application/json")
+	if link := pagination.LinkHeader(page.Links); link != "" {
+		// Add, а не Set: у устаревших маршрутов в Link уже есть successor-version
+		w.Header().Add("Link", link)
+	}
+	_, _ = w.Write(append(page.Body, '\n'))
+}
+
+func ptr[T any](v T) *T {
+	return &v
 }
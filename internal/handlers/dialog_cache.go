@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"messenger_frontend/internal/dto"
+	"messenger_frontend/internal/pagination"
+)
+
+const (
+	// staleCacheTTL — сколько хранится последний успешный ответ на случай деградации dialog-сервиса.
+	staleCacheTTL = 24 * time.Hour
+
+	revalidateAttempts = 5
+	revalidateBackoff  = time.Second
+)
+
+// listPage — страница списка в том виде, в каком она отдаётся и хранится в кэше: тело ответа
+// и ссылки для заголовка Link. Ссылки лежат отдельно, потому что в теле старых маршрутов их нет.
+type listPage struct {
+	Body  json.RawMessage `json:"body"`
+	Links dto.Links       `json:"links"`
+}
+
+// fetchFunc выполняет запрос к upstream и возвращает готовую страницу. Она может быть
+// вызвана в фоне после ответа клиенту, поэтому не должна ссылаться на *http.Request.
+type fetchFunc func(ctx context.Context) (listPage, error)
+
+// staleCache хранит в Redis последние ответы dialog-сервиса по пользователю и отдаёт их,
+// если upstream недоступен, параллельно обновляя кэш в фоне.
+type staleCache struct {
+	rdb        redis.UniversalClient
+	refreshing sync.Map
+}
+
+func newStaleCache(rdb redis.UniversalClient) *staleCache {
+	if rdb == nil {
+		return nil
+	}
+	return &staleCache{rdb: rdb}
+}
+
+// В ключах есть версия формата: после смены тела ответа старые записи не должны отдаваться.
+// Путь входит в ключ, потому что от него зависят ссылки на соседние страницы.
+func dialogsCacheKey(path string, userID int, page pagination.Page) string {
+	return fmt.Sprintf("cache:v5:dialogs:%d:%d:%d:%s", userID, page.Limit, page.Offset, path)
+}
+
+func messagesCacheKey(path, userID string, dialogID int, page pagination.Page) string {
+	return fmt.Sprintf("cache:v5:messages:%s:%d:%d:%d:%s", userID, dialogID, page.Limit, page.Offset, path)
+}
+
+// staleEligible сообщает, можно ли вместо ошибки отдать устаревший ответ: только когда
+// dialog-сервис недоступен, не успел ответить или перегружен. На остальные ошибки
+// повторный запрос дал бы тот же результат.
+func staleEligible(ctx context.Context, err error) bool {
+	if isTimeout(ctx, err) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+func (c *staleCache) store(ctx context.Context, key string, page listPage) {
+	if c == nil {
+		return
+	}
+	payload, err := json.Marshal(page)
+	if err != nil {
+		slog.WarnContext(ctx, "stale cache: store failed", "key", key, "error", err)
+		return
+	}
+	if err := c.rdb.Set(context.WithoutCancel(ctx), key, payload, staleCacheTTL).Err(); err != nil {
+		slog.WarnContext(ctx, "stale cache: store failed", "key", key, "error", err)
+	}
+}
+
+// serveStale отдаёт закэшированный ответ с пометкой о его устаревании и запускает
+// фоновое обновление, которое прекращается с отменой shutdown. Возвращает false,
+// если в кэше ничего нет.
+func (c *staleCache) serveStale(w http.ResponseWriter, r *http.Request, shutdown context.Context, key string, fetch fetchFunc) bool {
+	if c == nil || key == "" {
+		return false
+	}
+	payload, err := c.rdb.Get(context.WithoutCancel(r.Context()), key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			slog.WarnContext(r.Context(), "stale cache: load failed", "key", key, "error", err)
+		}
+		return false
+	}
+	var page listPage
+	if err := json.Unmarshal(payload, &page); err != nil || len(page.Body) == 0 {
+		slog.WarnContext(r.Context(), "stale cache: entry is malformed", "key", key, "error", err)
+		return false
+	}
+
+	c.revalidate(shutdown, key, fetch)
+
+	w.Header().Set("Warning", `110 - "Response is Stale"`)
+	w.Header().Set("X-Cache", "STALE")
+	writePage(w, page)
+	return true
+}
+
+// revalidate повторяет запрос к upstream с экспоненциальной паузой, пока тот не восстановится.
+// Для одного ключа одновременно работает не больше одного обновления. Отмена shutdown
+// прерывает и паузу, и текущий запрос.
+func (c *staleCache) revalidate(shutdown context.Context, key string, fetch fetchFunc) {
+	if _, busy := c.refreshing.LoadOrStore(key, struct{}{}); busy {
+		return
+	}
+	go func() {
+		defer c.refreshing.Delete(key)
+
+		backoff := revalidateBackoff
+		for attempt := 0; attempt < revalidateAttempts; attempt++ {
+			select {
+			case <-time.After(backoff):
+			case <-shutdown.Done():
+				return
+			}
+			backoff *= 2
+
+			ctx, cancel := context.WithTimeout(shutdown, defaultUpstreamTimeout)
+			page, err := fetch(ctx)
+			if err == nil {
+				c.store(ctx, key, page)
+				cancel()
+				return
+			}
+			retry := staleEligible(ctx, err)
+			cancel()
+			if shutdown.Err() != nil {
+				return
+			}
+			if !retry {
+				slog.Warn("stale cache: revalidation stopped", "key", key, "error", err)
+				return
+			}
+		}
+		slog.Warn("stale cache: upstream still unavailable, entry not refreshed", "key", key)
+	}()
+}
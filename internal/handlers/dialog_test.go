@@ -5,14 +5,22 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/GalahadKingsman/messenger_dialog/pkg/messenger_dialog_api"
+	"github.com/go-redis/redismock/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"messenger_frontend/internal/apierror"
 	"messenger_frontend/internal/middleware"
+	"messenger_frontend/internal/openapi/openapitest"
+	"messenger_frontend/internal/pagination"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 type mockDialogServiceClient struct {
@@ -46,7 +54,7 @@ func withUserContext(r *http.Request, userID string) *http.Request {
 
 func TestCreateDialogHandler_Success(t *testing.T) {
 	mockClient := new(mockDialogServiceClient)
-	handler := NewDialogHandlerService(mockClient)
+	handler := NewDialogHandlerService(mockClient, nil)
 
 	payload := map[string]interface{}{
 		"peer_id":     2,
@@ -68,6 +76,7 @@ func TestCreateDialogHandler_Success(t *testing.T) {
 	req = withUserContext(req, "1")
 	w := httptest.NewRecorder()
 	handler.CreateDialogHandler().ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "TestDialog")
@@ -75,7 +84,7 @@ func TestCreateDialogHandler_Success(t *testing.T) {
 
 func TestSendMessageHandler_Success(t *testing.T) {
 	mockClient := new(mockDialogServiceClient)
-	handler := NewDialogHandlerService(mockClient)
+	handler := NewDialogHandlerService(mockClient, nil)
 
 	payload := map[string]interface{}{
 		"dialog_id": 10,
@@ -96,17 +105,64 @@ func TestSendMessageHandler_Success(t *testing.T) {
 	req = withUserContext(req, "1")
 	w := httptest.NewRecorder()
 	handler.SendMessageHandler().ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "message_id")
 }
 
+func TestSendMessageHandler_MissingTimestamp(t *testing.T) {
+	mockClient := new(mockDialogServiceClient)
+	handler := NewDialogHandlerService(mockClient, nil)
+	mockClient.On("SendMessage", mock.Anything, mock.Anything).
+		Return(&messenger_dialog_api.SendMessageResponse{MessageId: 99}, nil)
+
+	// Без метки времени от upstream отдаём null, а не 1970-01-01
+	req := withUserContext(httptest.NewRequest(http.MethodPost, "/dialog/send", bytes.NewBufferString(`{"dialog_id":10,"text":"hi"}`)), "1")
+	w := httptest.NewRecorder()
+	handler.SendMessageHandler().ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message_id":99,"timestamp":null}`, w.Body.String())
+}
+
+func TestDialogHandlers_RejectOutOfRangeIDs(t *testing.T) {
+	mockClient := new(mockDialogServiceClient)
+	handler := NewDialogHandlerService(mockClient, nil)
+	mux := http.NewServeMux()
+	handler.RegisterHandlers(mux)
+	handler.RegisterLegacyHandlers(mux, middleware.Deprecation{})
+
+	// 4294967301 после усечения до int32 превратился бы в 5
+	for _, tc := range []struct {
+		method, path, body, code string
+	}{
+		{http.MethodPost, "/v1/dialogs", `{"peer_id":"4294967301","dialog_name":"x"}`, apierror.CodeInvalidArgument},
+		{http.MethodPost, "/dialog/send", `{"dialog_id":"4294967301","text":"hi"}`, apierror.CodeDialogIDInvalid},
+		{http.MethodPost, "/v1/dialogs/4294967301/messages", `{"text":"hi"}`, apierror.CodeDialogIDInvalid},
+		{http.MethodGet, "/v1/dialogs/4294967301/messages", "", apierror.CodeDialogIDInvalid},
+	} {
+		req := withUserContext(httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body)), "1")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, tc.path)
+		assert.Contains(t, w.Body.String(), `"code":"`+tc.code+`"`, tc.path)
+	}
+	mockClient.AssertNotCalled(t, "CreateDialog", mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "SendMessage", mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "GetDialogMessages", mock.Anything, mock.Anything)
+}
+
 func TestGetUserDialogsHandler_Success(t *testing.T) {
 	mockClient := new(mockDialogServiceClient)
-	handler := NewDialogHandlerService(mockClient)
+	handler := NewDialogHandlerService(mockClient, nil)
 
 	mockClient.On("GetUserDialogs", mock.Anything, &messenger_dialog_api.GetUserDialogsRequest{
 		UserId: 1,
+		Limit:  ptr(int32(pagination.DefaultLimit + 1)),
+		Offset: ptr(int32(0)),
 	}).Return(&messenger_dialog_api.GetUserDialogsResponse{
 		Dialogs: []*messenger_dialog_api.DialogInfo{
 			{
@@ -122,6 +178,7 @@ func TestGetUserDialogsHandler_Success(t *testing.T) {
 	req = withUserContext(req, "1")
 	w := httptest.NewRecorder()
 	handler.GetUserDialogsHandler().ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "peeruser")
@@ -129,10 +186,12 @@ func TestGetUserDialogsHandler_Success(t *testing.T) {
 
 func TestGetDialogMessagesHandler_Success(t *testing.T) {
 	mockClient := new(mockDialogServiceClient)
-	handler := NewDialogHandlerService(mockClient)
+	handler := NewDialogHandlerService(mockClient, nil)
 
 	mockClient.On("GetDialogMessages", mock.Anything, &messenger_dialog_api.GetDialogMessagesRequest{
 		DialogId: 10,
+		Limit:    ptr(int32(pagination.DefaultLimit + 1)),
+		Offset:   ptr(int32(0)),
 	}).Return(&messenger_dialog_api.GetDialogMessagesResponse{
 		Messages: []*messenger_dialog_api.Message{
 			{
@@ -147,7 +206,323 @@ func TestGetDialogMessagesHandler_Success(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/dialog/messages?dialog_id=10", nil)
 	w := httptest.NewRecorder()
 	handler.GetDialogMessagesHandler().ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "hi!")
 }
+
+func TestSendMessageHandler_UpstreamTimeout(t *testing.T) {
+	mockClient := new(mockDialogServiceClient)
+	handler := NewDialogHandlerService(mockClient, nil)
+
+	mockClient.On("SendMessage", mock.Anything, mock.Anything).
+		Return((*messenger_dialog_api.SendMessageResponse)(nil), status.Error(codes.DeadlineExceeded, "deadline exceeded"))
+
+	body, _ := json.Marshal(map[string]interface{}{"dialog_id": 10, "text": "Hello"})
+	req := httptest.NewRequest(http.MethodPost, "/dialog/send", bytes.NewBuffer(body))
+	req = withUserContext(req, "1")
+	w := httptest.NewRecorder()
+	handler.SendMessageHandler().ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+}
+
+func TestGetUserDialogsHandler_ServesStaleOnUpstreamError(t *testing.T) {
+	mockClient := new(mockDialogServiceClient)
+	mockRedis, redisMock := redismock.NewClientMock()
+	handler := NewDialogHandlerService(mockClient, mockRedis)
+
+	mockClient.On("GetUserDialogs", mock.Anything, mock.Anything).
+		Return((*messenger_dialog_api.GetUserDialogsResponse)(nil), status.Error(codes.Unavailable, "unavailable"))
+	redisMock.ExpectGet(dialogsCacheKey("/dialog/user", 1, pagination.Page{Scope: "dialogs:1", Limit: pagination.DefaultLimit})).SetVal(`{"body":{"dialogs":[{"dialog_id":1,"peer_id":2,"peer_login":"cached","last_message":""}]},"links":{"next":"/dialog/user?cursor=c"}}`)
+
+	req := httptest.NewRequest(http.MethodGet, "/dialog/user", nil)
+	req = withUserContext(req, "1")
+	w := httptest.NewRecorder()
+	handler.GetUserDialogsHandler().ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "STALE", w.Header().Get("X-Cache"))
+	assert.NotEmpty(t, w.Header().Get("Warning"))
+	assert.JSONEq(t, `{"dialogs":[{"dialog_id":1,"peer_id":2,"peer_login":"cached","last_message":""}]}`, w.Body.String())
+	assert.Equal(t, `</dialog/user?cursor=c>; rel="next"`, w.Header().Get("Link"))
+}
+
+func TestGetUserDialogsHandler_NoStaleOnClientErrors(t *testing.T) {
+	for _, code := range []codes.Code{codes.NotFound, codes.InvalidArgument, codes.PermissionDenied} {
+		t.Run(code.String(), func(t *testing.T) {
+			mockClient := new(mockDialogServiceClient)
+			mockRedis, redisMock := redismock.NewClientMock()
+			handler := NewDialogHandlerService(mockClient, mockRedis)
+
+			mockClient.On("GetUserDialogs", mock.Anything, mock.Anything).
+				Return((*messenger_dialog_api.GetUserDialogsResponse)(nil), status.Error(code, "rejected"))
+			redisMock.ExpectGet(dialogsCacheKey("/v1/dialogs", 1, pagination.Page{Scope: "dialogs:1", Limit: pagination.DefaultLimit})).SetVal(`{"body":{"dialogs":[],"links":{}},"links":{}}`)
+
+			req := withUserContext(httptest.NewRequest(http.MethodGet, "/v1/dialogs", nil), "1")
+			w := httptest.NewRecorder()
+			handler.GetUserDialogsHandler().ServeHTTP(w, req)
+			openapitest.Check(t, req, w)
+
+			assert.Less(t, w.Code, http.StatusInternalServerError)
+			assert.GreaterOrEqual(t, w.Code, http.StatusBadRequest)
+			assert.Empty(t, w.Header().Get("X-Cache"))
+			// В кэш даже не заглядываем
+			assert.Error(t, redisMock.ExpectationsWereMet())
+		})
+	}
+
+	// Клиент ушёл сам — устаревший ответ ему не нужен
+	mockClient := new(mockDialogServiceClient)
+	mockRedis, redisMock := redismock.NewClientMock()
+	handler := NewDialogHandlerService(mockClient, mockRedis)
+	mockClient.On("GetUserDialogs", mock.Anything, mock.Anything).
+		Return((*messenger_dialog_api.GetUserDialogsResponse)(nil), status.Error(codes.Unavailable, "unavailable"))
+	redisMock.ExpectGet(dialogsCacheKey("/v1/dialogs", 1, pagination.Page{Scope: "dialogs:1", Limit: pagination.DefaultLimit})).SetVal(`{"body":{"dialogs":[],"links":{}},"links":{}}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := withUserContext(httptest.NewRequest(http.MethodGet, "/v1/dialogs", nil).WithContext(ctx), "1")
+	w := httptest.NewRecorder()
+	handler.GetUserDialogsHandler().ServeHTTP(w, req)
+
+	assert.Empty(t, w.Header().Get("X-Cache"))
+	assert.Error(t, redisMock.ExpectationsWereMet())
+}
+
+func TestGetUserDialogsHandler_CachesFreshResponse(t *testing.T) {
+	mockClient := new(mockDialogServiceClient)
+	mockRedis, redisMock := redismock.NewClientMock()
+	handler := NewDialogHandlerService(mockClient, mockRedis)
+
+	mockClient.On("GetUserDialogs", mock.Anything, mock.Anything).
+		Return(&messenger_dialog_api.GetUserDialogsResponse{}, nil)
+	redisMock.ExpectSet(dialogsCacheKey("/dialog/user", 1, pagination.Page{Scope: "dialogs:1", Limit: pagination.DefaultLimit}), []byte(`{"body":{"dialogs":[]},"links":{}}`), staleCacheTTL).SetVal("OK")
+
+	req := httptest.NewRequest(http.MethodGet, "/dialog/user", nil)
+	req = withUserContext(req, "1")
+	w := httptest.NewRecorder()
+	handler.GetUserDialogsHandler().ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-Cache"))
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestGetUserDialogsHandler_StaleBodyMatchesFresh(t *testing.T) {
+	payload := `{"body":{"dialogs":[],"links":{}},"links":{}}`
+	key := dialogsCacheKey("/v1/dialogs", 1, pagination.Page{Scope: "dialogs:1", Limit: pagination.DefaultLimit})
+	mockRedis, redisMock := redismock.NewClientMock()
+
+	fresh := new(mockDialogServiceClient)
+	fresh.On("GetUserDialogs", mock.Anything, mock.Anything).Return(&messenger_dialog_api.GetUserDialogsResponse{}, nil)
+	redisMock.ExpectSet(key, []byte(payload), staleCacheTTL).SetVal("OK")
+	w := httptest.NewRecorder()
+	NewDialogHandlerService(fresh, mockRedis).getUserDialogs(false).
+		ServeHTTP(w, withUserContext(httptest.NewRequest(http.MethodGet, "/v1/dialogs", nil), "1"))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	down := new(mockDialogServiceClient)
+	down.On("GetUserDialogs", mock.Anything, mock.Anything).
+		Return((*messenger_dialog_api.GetUserDialogsResponse)(nil), status.Error(codes.Unavailable, "unavailable"))
+	redisMock.ExpectGet(key).SetVal(payload)
+	stale := httptest.NewRecorder()
+	NewDialogHandlerService(down, mockRedis).getUserDialogs(false).
+		ServeHTTP(stale, withUserContext(httptest.NewRequest(http.MethodGet, "/v1/dialogs", nil), "1"))
+	require.Equal(t, "STALE", stale.Header().Get("X-Cache"))
+
+	// Иначе ETag меняется при переходе на кэш, и условный запрос не получает 304
+	assert.Equal(t, w.Body.String(), stale.Body.String())
+}
+
+func TestStaleCache_RevalidateStopsOnShutdown(t *testing.T) {
+	mockRedis, _ := redismock.NewClientMock()
+	cache := newStaleCache(mockRedis)
+	shutdown, cancel := context.WithCancel(context.Background())
+
+	fetched := make(chan struct{}, 1)
+	cache.revalidate(shutdown, "key", func(ctx context.Context) (listPage, error) {
+		fetched <- struct{}{}
+		return listPage{}, nil
+	})
+	cancel()
+
+	// Пауза перед первой попыткой прерывается, запрос к upstream не уходит
+	assert.Eventually(t, func() bool {
+		_, busy := cache.refreshing.Load("key")
+		return !busy
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, fetched)
+}
+
+func TestV1Dialogs_Routes(t *testing.T) {
+	mockClient := new(mockDialogServiceClient)
+	handler := NewDialogHandlerService(mockClient, nil)
+	mux := http.NewServeMux()
+	handler.RegisterHandlers(mux)
+
+	mockClient.On("SendMessage", mock.Anything, &messenger_dialog_api.SendMessageRequest{
+		DialogId: 10,
+		UserId:   1,
+		Text:     "Hello world",
+	}).Return(&messenger_dialog_api.SendMessageResponse{
+		MessageId: 99,
+		Timestamp: timestamppb.Now(),
+	}, nil)
+
+	// Идентификатор диалога берётся из пути, а не из тела
+	req := httptest.NewRequest(http.MethodPost, "/v1/dialogs/10/messages", bytes.NewBufferString(`{"text":"Hello world"}`))
+	req = withUserContext(req, "1")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), "message_id")
+
+	req = httptest.NewRequest(http.MethodDelete, "/v1/dialogs/10/messages", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD, POST", w.Header().Get("Allow"))
+}
+
+func TestLegacyDialogRoutes_Deprecated(t *testing.T) {
+	mockClient := new(mockDialogServiceClient)
+	handler := NewDialogHandlerService(mockClient, nil)
+	mux := http.NewServeMux()
+	handler.RegisterLegacyHandlers(mux, middleware.Deprecation{
+		Since:  time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		Sunset: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	mockClient.On("GetUserDialogs", mock.Anything, &messenger_dialog_api.GetUserDialogsRequest{
+		UserId: 1,
+		Limit:  ptr(int32(pagination.DefaultLimit + 1)),
+		Offset: ptr(int32(0)),
+	}).Return(&messenger_dialog_api.GetUserDialogsResponse{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/dialog/user", nil)
+	req = withUserContext(req, "1")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "@1751328000", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Thu, 01 Jan 2026 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/dialogs>; rel="successor-version"`, w.Header().Get("Link"))
+}
+
+func TestDialogRoutes_WireFormat(t *testing.T) {
+	mockClient := new(mockDialogServiceClient)
+	handler := NewDialogHandlerService(mockClient, nil)
+	mux := http.NewServeMux()
+	handler.RegisterHandlers(mux)
+	handler.RegisterLegacyHandlers(mux, middleware.Deprecation{})
+
+	mockClient.On("GetDialogMessages", mock.Anything, mock.Anything).Return(&messenger_dialog_api.GetDialogMessagesResponse{
+		Messages: []*messenger_dialog_api.Message{{Id: 7, UserId: 1, Text: "hi", Timestamp: timestamppb.New(time.UnixMilli(1751371445123))}},
+	}, nil)
+
+	// /v1 отдаёт строковые идентификаторы и миллисекунды, старый маршрут — прежний формат
+	for path, want := range map[string]string{
+		"/v1/dialogs/10/messages":       `{"id":"7","user_id":"1","text":"hi","timestamp":"2025-07-01T12:04:05.123Z"}`,
+		"/dialog/messages?dialog_id=10": `{"id":7,"user_id":1,"text":"hi","timestamp":"2025-07-01T12:04:05Z"}`,
+	} {
+		req := withUserContext(httptest.NewRequest(http.MethodGet, path, nil), "1")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		openapitest.Check(t, req, w)
+
+		require.Equal(t, http.StatusOK, w.Code, path)
+		var list struct {
+			Messages []json.RawMessage `json:"messages"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.Len(t, list.Messages, 1)
+		assert.JSONEq(t, want, string(list.Messages[0]), path)
+	}
+}
+
+func TestGetDialogMessagesHandler_Paging(t *testing.T) {
+	mockClient := new(mockDialogServiceClient)
+	handler := NewDialogHandlerService(mockClient, nil)
+	mux := http.NewServeMux()
+	handler.RegisterHandlers(mux)
+
+	message := func(id int32) *messenger_dialog_api.Message {
+		return &messenger_dialog_api.Message{Id: id, UserId: 1, Text: "m", Timestamp: timestamppb.Now()}
+	}
+	// Шлюз просит на один элемент больше, чтобы узнать о следующей странице
+	mockClient.On("GetDialogMessages", mock.Anything, &messenger_dialog_api.GetDialogMessagesRequest{
+		DialogId: 10, Limit: ptr(int32(3)), Offset: ptr(int32(0)),
+	}).Return(&messenger_dialog_api.GetDialogMessagesResponse{
+		Messages: []*messenger_dialog_api.Message{message(1), message(2), message(3)},
+	}, nil)
+	mockClient.On("GetDialogMessages", mock.Anything, &messenger_dialog_api.GetDialogMessagesRequest{
+		DialogId: 10, Limit: ptr(int32(3)), Offset: ptr(int32(2)),
+	}).Return(&messenger_dialog_api.GetDialogMessagesResponse{
+		Messages: []*messenger_dialog_api.Message{message(3)},
+	}, nil)
+
+	req := withUserContext(httptest.NewRequest(http.MethodGet, "/v1/dialogs/10/messages?limit=2", nil), "1")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var first struct {
+		Messages []json.RawMessage `json:"messages"`
+		Links    map[string]string `json:"links"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+	assert.Len(t, first.Messages, 2)
+	require.Contains(t, first.Links, "next")
+	assert.NotContains(t, first.Links, "prev")
+	assert.Equal(t, "<"+first.Links["next"]+`>; rel="next"`, w.Header().Get("Link"))
+
+	req = withUserContext(httptest.NewRequest(http.MethodGet, first.Links["next"], nil), "1")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"prev"`)
+	assert.NotContains(t, w.Body.String(), `"next"`)
+	mockClient.AssertExpectations(t)
+
+	// Курсор другого пользователя не принимается
+	req = withUserContext(httptest.NewRequest(http.MethodGet, first.Links["next"], nil), "2")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), apierror.CodePagingInvalid)
+
+	req = withUserContext(httptest.NewRequest(http.MethodGet, "/v1/dialogs/10/messages?limit=1000", nil), "1")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"limit"`)
+
+	// Старый маршрут отдаёт ссылки только в заголовке Link, тело остаётся прежним
+	handler.RegisterLegacyHandlers(mux, middleware.Deprecation{})
+	req = withUserContext(httptest.NewRequest(http.MethodGet, "/dialog/messages?dialog_id=10&limit=2", nil), "1")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	openapitest.Check(t, req, w)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var legacy map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &legacy))
+	assert.NotContains(t, legacy, "links")
+	assert.Contains(t, w.Header().Get("Link"), `rel="next"`)
+	assert.Contains(t, w.Header().Get("Link"), "/dialog/messages?")
+}
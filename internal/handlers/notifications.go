@@ -1,15 +1,38 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"io"
-	"log"
+	"log/slog"
+	"messenger_frontend/internal/apierror"
+	"messenger_frontend/internal/logging"
 	"messenger_frontend/internal/middleware"
+	"messenger_frontend/internal/router"
+	"messenger_frontend/internal/tracing"
 	"net/http"
 	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// TimeoutHeader передаёт upstream-сервису оставшийся бюджет запроса в миллисекундах.
+const TimeoutHeader = "X-Request-Timeout"
+
+// longPollEndpoint — единственный маршрут, который держит соединение открытым до прихода уведомления.
+const longPollEndpoint = "/longpoll"
+
 type NotificationHandler struct {
-	BaseURL string
+	baseURL   atomic.Pointer[string]
+	longPolls atomic.Int64
+	// Shutdown закрывается при остановке шлюза; открытые long-poll запросы при этом
+	// завершаются ответом с просьбой переподключиться.
+	Shutdown <-chan struct{}
 }
 
 type Notification struct {
@@ -18,10 +41,26 @@ type Notification struct {
 }
 
 func NewNotificationHandler(baseURL string) *NotificationHandler {
-	return &NotificationHandler{BaseURL: baseURL}
+	h := &NotificationHandler{}
+	h.SetBaseURL(baseURL)
+	return h
+}
+
+func (h *NotificationHandler) BaseURL() string {
+	return *h.baseURL.Load()
+}
+
+// ActiveLongPolls возвращает число открытых сейчас long-poll соединений.
+func (h *NotificationHandler) ActiveLongPolls() int64 {
+	return h.longPolls.Load()
 }
 
-func (h *NotificationHandler) RegisterHandlers(mux *http.ServeMux) {
+// SetBaseURL меняет адрес notifications-сервиса; запросы в обработке продолжают идти по старому.
+func (h *NotificationHandler) SetBaseURL(baseURL string) {
+	h.baseURL.Store(&baseURL)
+}
+
+func (h *NotificationHandler) RegisterHandlers(mux router.Mux) {
 	mux.HandleFunc("/notifications", h.proxy(""))
 	mux.HandleFunc("/notifications/clear", h.proxy("/clear"))
 	mux.HandleFunc("/notifications/longpoll", h.proxy("/longpoll"))
@@ -29,33 +68,90 @@ func (h *NotificationHandler) RegisterHandlers(mux *http.ServeMux) {
 
 func (h *NotificationHandler) proxy(endpoint string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("[Gateway] proxy %s %s → %s%s?%s",
-			r.Method, r.URL.Path,
-			h.BaseURL, endpoint, r.URL.RawQuery,
-		)
+		baseURL := h.BaseURL()
 		userID := r.Context().Value(middleware.UserIDKey)
 		userIDStr, ok := userID.(string)
 		if !ok {
-			http.Error(w, "user ID missing", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.Unauthorized(apierror.CodeUnauthenticated))
 			return
 		}
 
-		proxyURL, _ := url.Parse(h.BaseURL + endpoint)
+		proxyURL, _ := url.Parse(baseURL + endpoint)
 		query := r.URL.Query()
 		query.Set("userID", userIDStr)
 		proxyURL.RawQuery = query.Encode()
 
-		proxyReq, _ := http.NewRequest(r.Method, proxyURL.String(), r.Body)
+		ctx := r.Context()
+		if endpoint == longPollEndpoint {
+			h.longPolls.Add(1)
+			defer h.longPolls.Add(-1)
+		}
+		if endpoint == longPollEndpoint && h.Shutdown != nil {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithCancel(ctx)
+			defer cancel()
+			// ctx ниже переопределяется спаном, поэтому горутина получает свой канал
+			done := ctx.Done()
+			go func() {
+				select {
+				case <-h.Shutdown:
+					cancel()
+				case <-done:
+				}
+			}()
+			if h.shuttingDown() {
+				writeReconnect(w, r)
+				return
+			}
+		}
+
+		ctx, span := tracing.Tracer().Start(ctx, "notifications "+r.Method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.PeerService("notifications"),
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(proxyURL.Path),
+			),
+		)
+		defer span.End()
+
+		proxyReq, _ := http.NewRequestWithContext(ctx, r.Method, proxyURL.String(), r.Body)
 		proxyReq.Header = r.Header.Clone()
+		proxyReq.Header.Del(TimeoutHeader)
+		tracing.Inject(ctx, proxyReq.Header)
+		if id := logging.RequestID(r.Context()); id != "" {
+			proxyReq.Header.Set(middleware.RequestIDHeader, id)
+		}
+		if deadline, ok := r.Context().Deadline(); ok {
+			proxyReq.Header.Set(TimeoutHeader, strconv.FormatInt(time.Until(deadline).Milliseconds(), 10))
+		}
 
+		start := time.Now()
 		resp, err := http.DefaultClient.Do(proxyReq)
+		logging.ObserveUpstream(ctx, "notifications", time.Since(start))
 		if err != nil {
-			http.Error(w, "proxy error", http.StatusBadGateway)
+			span.SetStatus(codes.Error, err.Error())
+			slog.WarnContext(r.Context(), "notifications proxy failed", "upstream", "notifications",
+				"latency_ms", time.Since(start).Milliseconds(), "error", err)
+			if errors.Is(err, context.Canceled) && r.Context().Err() == nil && h.shuttingDown() {
+				writeReconnect(w, r)
+				return
+			}
+			if isTimeout(r.Context(), err) {
+				apierror.Write(w, r, apierror.New(http.StatusGatewayTimeout, apierror.CodeDeadlineExceeded))
+				return
+			}
+			apierror.Write(w, r, apierror.New(http.StatusBadGateway, apierror.CodeNotificationsUnavailable))
 			return
 		}
 		defer resp.Body.Close()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, resp.Status)
+		}
 
-		log.Printf("[Gateway] upstream returned %d %s", resp.StatusCode, resp.Status)
+		slog.DebugContext(r.Context(), "notifications proxy", "upstream", "notifications", "endpoint", endpoint,
+			"status", resp.StatusCode, "latency_ms", time.Since(start).Milliseconds())
 
 		for k, vv := range resp.Header {
 			for _, v := range vv {
@@ -67,6 +163,23 @@ func (h *NotificationHandler) proxy(endpoint string) http.HandlerFunc {
 	}
 }
 
+func (h *NotificationHandler) shuttingDown() bool {
+	select {
+	case <-h.Shutdown:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeReconnect просит клиента повторить long-poll: шлюз останавливается,
+// и запрос попадёт на другой экземпляр.
+func writeReconnect(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Connection", "close")
+	w.Header().Set("Retry-After", "0")
+	apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, apierror.CodeServerRestarting))
+}
+
 func (h *NotificationHandler) RegisterHandlersAndGet(endpoint string) http.HandlerFunc {
 	return h.proxy(endpoint)
 }
@@ -7,8 +7,15 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"messenger_frontend/internal/logging"
 	"messenger_frontend/internal/middleware"
+	"messenger_frontend/internal/openapi/openapitest"
 )
 
 func TestNotificationHandler_proxy_Success(t *testing.T) {
@@ -82,3 +89,105 @@ func TestNotificationHandler_proxy_UpstreamError(t *testing.T) {
 		t.Errorf("expected status 502 Bad Gateway, got %d", resp.StatusCode)
 	}
 }
+
+func TestNotificationHandler_proxy_PropagatesDeadline(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(TimeoutHeader) == "" {
+			t.Errorf("expected %s header to be set", TimeoutHeader)
+		}
+		<-r.Context().Done()
+	}))
+	defer upstream.Close()
+
+	handler := NewNotificationHandler(upstream.URL)
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), middleware.UserIDKey, "12345"), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/notifications/longpoll", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	handler.RegisterHandlersAndGet("/longpoll").ServeHTTP(w, req)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected status 504 Gateway Timeout, got %d", w.Code)
+	}
+}
+
+func TestNotificationHandler_longpoll_ReconnectOnShutdown(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer upstream.Close()
+
+	shutdown := make(chan struct{})
+	handler := NewNotificationHandler(upstream.URL)
+	handler.Shutdown = shutdown
+
+	req := httptest.NewRequest(http.MethodGet, "/notifications/longpoll", nil)
+	ctx := logging.WithRequestID(context.WithValue(req.Context(), middleware.UserIDKey, "12345"), "req-1")
+	req = req.WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	var active int64
+	time.AfterFunc(50*time.Millisecond, func() {
+		active = handler.ActiveLongPolls()
+		close(shutdown)
+	})
+	handler.RegisterHandlersAndGet("/longpoll").ServeHTTP(w, req)
+
+	if active != 1 || handler.ActiveLongPolls() != 0 {
+		t.Errorf("expected 1 active long-poll during request and 0 after, got %d and %d", active, handler.ActiveLongPolls())
+	}
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+	if !strings.Contains(w.Body.String(), `"code":"server_restarting"`) || !strings.Contains(w.Body.String(), `"request_id":"req-1"`) {
+		t.Errorf("expected server_restarting error with request_id, got %s", w.Body.String())
+	}
+	openapitest.Check(t, req, w)
+}
+
+func TestNotificationHandler_longpoll_ReconnectTranslated(t *testing.T) {
+	shutdown := make(chan struct{})
+	close(shutdown)
+	handler := NewNotificationHandler("http://notifications")
+	handler.Shutdown = shutdown
+
+	req := httptest.NewRequest(http.MethodGet, "/notifications/longpoll", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "12345"))
+	req.Header.Set("Accept-Language", "en")
+	w := httptest.NewRecorder()
+	handler.RegisterHandlersAndGet("/longpoll").ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"message":"Server is restarting, please reconnect"`) {
+		t.Errorf("expected English reconnect message, got %s", w.Body.String())
+	}
+}
+
+func TestNotificationHandler_proxy_PropagatesTraceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	parent := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled})
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("traceparent"); !strings.Contains(got, traceID.String()) {
+			t.Errorf("expected traceparent with trace %s, got %q", traceID, got)
+		}
+	}))
+	defer upstream.Close()
+
+	handler := NewNotificationHandler(upstream.URL)
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), parent)
+	ctx = context.WithValue(ctx, middleware.UserIDKey, "12345")
+	req := httptest.NewRequest(http.MethodGet, "/notifications", nil).WithContext(ctx)
+
+	handler.RegisterHandlersAndGet("/notifications").ServeHTTP(httptest.NewRecorder(), req)
+}
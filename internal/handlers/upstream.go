@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"messenger_frontend/internal/apierror"
+)
+
+// defaultUpstreamTimeout применяется, если у входящего запроса нет собственного дедлайна.
+const defaultUpstreamTimeout = 5 * time.Second
+
+// upstreamContext возвращает контекст для вызова upstream-сервиса, производный от контекста запроса,
+// чтобы вызов прерывался при отключении клиента.
+func upstreamContext(r *http.Request) (context.Context, context.CancelFunc) {
+	if _, ok := r.Context().Deadline(); ok {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), defaultUpstreamTimeout)
+}
+
+func isTimeout(ctx context.Context, err error) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		status.Code(err) == codes.DeadlineExceeded
+}
+
+// writeUpstreamError переводит ошибку upstream в ответ API по коду gRPC. Если истёк дедлайн
+// самого запроса, отвечает 504 независимо от кода. key — ключ каталога i18n для текста ошибок 5xx.
+func writeUpstreamError(w http.ResponseWriter, r *http.Request, ctx context.Context, err error, key string) {
+	if isTimeout(ctx, err) {
+		apierror.Write(w, r, apierror.New(http.StatusGatewayTimeout, apierror.CodeDeadlineExceeded))
+		return
+	}
+	apierror.Write(w, r, apierror.FromGRPC(err, key))
+}
+
+// writeJSON отвечает телом v в JSON с кодом status.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
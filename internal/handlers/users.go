@@ -1,24 +1,29 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
-	"fmt"
 	uapi "github.com/GalahadKingsman/messenger_users/pkg/messenger_users_api"
 	"github.com/redis/go-redis/v9"
 	"io"
-	"log"
+	"log/slog"
+	"messenger_frontend/internal/apierror"
+	"messenger_frontend/internal/audit"
+	"messenger_frontend/internal/dto"
+	"messenger_frontend/internal/metrics"
+	"messenger_frontend/internal/middleware"
+	"messenger_frontend/internal/router"
 	"net/http"
 	"strconv"
-	"time"
 )
 
 type UserHandlerService struct {
 	UserServiceClient uapi.UserServiceClient
-	redisClient       *redis.Client
+	redisClient       redis.UniversalClient
+	// Audit получает успешные и неудачные входы.
+	Audit *audit.Logger
 }
 
-func NewUserHandlerService(client uapi.UserServiceClient, redisClient *redis.Client) *UserHandlerService {
+func NewUserHandlerService(client uapi.UserServiceClient, redisClient redis.UniversalClient) *UserHandlerService {
 	return &UserHandlerService{
 		UserServiceClient: client,
 		redisClient:       redisClient,
@@ -26,13 +31,51 @@ func NewUserHandlerService(client uapi.UserServiceClient, redisClient *redis.Cli
 
 }
 
-func (u *UserHandlerService) RegisterHandlers(mux *http.ServeMux) {
-	mux.HandleFunc("/users/create", u.CreateUserHandler())
-	mux.HandleFunc("/users/get", u.GetUserHandler())
-	mux.HandleFunc("/users/login", u.LoginHandler())
+func (u *UserHandlerService) RegisterHandlers(mux router.Mux) {
+	mux.HandleFunc("GET /v1/users", u.getUsers(false))
+	mux.HandleFunc("GET /v1/users/{id}", u.GetUserByIDHandler())
+	mux.HandleFunc("POST /users/create", u.CreateUserHandler())
+	mux.HandleFunc("POST /users/login", u.LoginHandler())
 }
 
+// RegisterLegacyHandlers регистрирует маршруты до /v1 как устаревшие синонимы в прежнем формате ответа.
+func (u *UserHandlerService) RegisterLegacyHandlers(mux router.Mux, dep middleware.Deprecation) {
+	mux.Handle("GET /users/get", middleware.DeprecatedMiddleware(dep, "/v1/users", u.GetUserHandler()))
+}
+
+// GetUserByIDHandler отдаёт одного пользователя или 404.
+func (u *UserHandlerService) GetUserByIDHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id <= 0 {
+			apierror.Write(w, r, apierror.BadRequest(apierror.CodeUserIDInvalid))
+			return
+		}
+
+		ctx, cancel := upstreamContext(r)
+		defer cancel()
+		resp, err := u.UserServiceClient.GetUser(ctx, &uapi.GetUserRequest{Id: &id})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "GetUser failed", "upstream", "users", "error", err)
+			writeUpstreamError(w, r, ctx, err, apierror.MsgGetUserFailed)
+			return
+		}
+		if len(resp.Users) == 0 {
+			apierror.Write(w, r, apierror.NotFound(apierror.CodeUserNotFound))
+			return
+		}
+		json.NewEncoder(w).Encode(dto.UsersFromProto(resp).Users[0])
+	}
+}
+
+// GetUserHandler обслуживает устаревший GET /users/get.
 func (u *UserHandlerService) GetUserHandler() http.HandlerFunc {
+	return u.getUsers(true)
+}
+
+func (u *UserHandlerService) getUsers(legacy bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
@@ -50,7 +93,7 @@ func (u *UserHandlerService) GetUserHandler() http.HandlerFunc {
 		if idStr := query.Get("id"); idStr != "" {
 			idVal, err := strconv.ParseInt(idStr, 10, 64)
 			if err != nil || idVal <= 0 {
-				http.Error(w, `{"error":"id должен быть положительным числом"}`, http.StatusBadRequest)
+				apierror.Write(w, r, apierror.BadRequest(apierror.CodeUserIDInvalid))
 				return
 			}
 			idPtr = &idVal
@@ -74,11 +117,11 @@ func (u *UserHandlerService) GetUserHandler() http.HandlerFunc {
 		// Проверка: должен быть хотя бы один параметр
 		if idPtr == nil && loginPtr == nil && firstNamePtr == nil &&
 			lastNamePtr == nil && emailPtr == nil && phonePtr == nil {
-			http.Error(w, `{"error":"нужно указать хотя бы один параметр"}`, http.StatusBadRequest)
+			apierror.Write(w, r, apierror.BadRequest(apierror.CodeSearchParamsRequired))
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ctx, cancel := upstreamContext(r)
 		defer cancel()
 
 		req := &uapi.GetUserRequest{
@@ -92,55 +135,36 @@ func (u *UserHandlerService) GetUserHandler() http.HandlerFunc {
 
 		resp, err := u.UserServiceClient.GetUser(ctx, req)
 		if err != nil {
-			log.Printf("GetUser error: %v", err)
-			http.Error(w, `{"error":"не удалось получить пользователя"}`, http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "GetUser failed", "upstream", "users", "error", err)
+			writeUpstreamError(w, r, ctx, err, apierror.MsgGetUserFailed)
 			return
 		}
 
-		// Формируем JSON-ответ
-		users := make([]map[string]interface{}, 0, len(resp.Users))
-		for _, u := range resp.Users {
-			users = append(users, map[string]interface{}{
-				"id":         u.Id,
-				"login":      u.Login,
-				"first_name": u.FirstName,
-				"last_name":  u.LastName,
-				"email":      u.Email,
-				"phone":      u.Phone,
-			})
+		if legacy {
+			json.NewEncoder(w).Encode(dto.UsersFromProto(resp).Legacy())
+			return
 		}
-
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"users": users,
-		})
+		json.NewEncoder(w).Encode(dto.UsersFromProto(resp))
 	}
 }
 
+// LoginHandler отвечает в прежнем формате: у входа пока нет маршрута /v1.
 func (u *UserHandlerService) LoginHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		if r.Method != http.MethodPost {
-			http.Error(w, `{"error":"только POST запрос разрешен"}`, http.StatusMethodNotAllowed)
-			return
-		}
-
-		var body struct {
-			Login    string `json:"login"`
-			Password string `json:"password"`
-		}
-
+		var body dto.LoginRequest
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			http.Error(w, `{"error":"не удалось разобрать тело запроса"}`, http.StatusBadRequest)
+			apierror.Write(w, r, apierror.BadRequest(apierror.CodeInvalidBody))
 			return
 		}
 
 		if body.Login == "" || body.Password == "" {
-			http.Error(w, `{"error":"login и password обязательны"}`, http.StatusBadRequest)
+			apierror.Write(w, r, apierror.BadRequest(apierror.CodeCredentialsRequired))
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ctx, cancel := upstreamContext(r)
 		defer cancel()
 
 		req := &uapi.LoginRequest{
@@ -150,27 +174,27 @@ func (u *UserHandlerService) LoginHandler() http.HandlerFunc {
 
 		resp, err := u.UserServiceClient.Login(ctx, req)
 		if err != nil {
-			log.Printf("Login error: %v", err)
-			http.Error(w, `{"error":"ошибка сервера при входе"}`, http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "Login failed", "upstream", "users", "error", err)
+			metrics.Logins.WithLabelValues("error").Inc()
+			writeUpstreamError(w, r, ctx, err, apierror.MsgLoginFailed)
 			return
 		}
-		fmt.Printf("RESP FROM USERS: %+v\n", resp)
 		if resp.Token == "" {
-			http.Error(w, `{"error":"некорректный логин или пароль"}`, http.StatusUnauthorized)
+			metrics.Logins.WithLabelValues("failure").Inc()
+			u.Audit.RecordRequest(r, audit.Event{Type: audit.LoginFailure, Login: body.Login, Reason: "invalid credentials"})
+			apierror.Write(w, r, apierror.Unauthorized(apierror.CodeInvalidCredentials))
 			return
 		}
 		token := resp.Token
-		err = u.redisClient.Set(context.Background(), "token:"+strconv.Itoa(int(resp.UserId)), token, 0).Err()
+		err = u.redisClient.Set(ctx, "token:"+strconv.Itoa(int(resp.UserId)), token, 0).Err()
 		if err != nil {
-			http.Error(w, "failed to save token", http.StatusInternalServerError)
+			apierror.Write(w, r, apierror.Internal(apierror.MsgTokenSaveFailed))
 			return
 		}
-		response := map[string]interface{}{
-			"message": resp.Message,
-			"user_id": resp.UserId,
-			"token":   resp.Token,
-		}
-		json.NewEncoder(w).Encode(response)
+		metrics.Logins.WithLabelValues("success").Inc()
+		u.Audit.RecordRequest(r, audit.Event{Type: audit.LoginSuccess, Login: body.Login,
+			UserID: strconv.Itoa(int(resp.UserId))})
+		json.NewEncoder(w).Encode(dto.LoginFromProto(resp).Legacy())
 
 	}
 
@@ -178,33 +202,32 @@ func (u *UserHandlerService) LoginHandler() http.HandlerFunc {
 
 func (u *UserHandlerService) CreateUserHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
-			return
-		}
-
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
-			http.Error(w, "Не удалось прочитать тело запроса", http.StatusBadRequest)
+			apierror.Write(w, r, apierror.BadRequest(apierror.CodeInvalidBody))
 			return
 		}
 		defer r.Body.Close()
 
-		var req uapi.CreateRequest
+		var req dto.CreateUserRequest
 		if err := json.Unmarshal(body, &req); err != nil {
-			http.Error(w, "Некорректный JSON", http.StatusBadRequest)
+			apierror.Write(w, r, apierror.BadRequest(apierror.CodeInvalidBody))
 			return
 		}
 
+		ctx, cancel := upstreamContext(r)
+		defer cancel()
+
 		// Вызов gRPC-метода
-		resp, err := u.UserServiceClient.CreateUser(r.Context(), &req)
+		resp, err := u.UserServiceClient.CreateUser(ctx, req.Proto())
 		if err != nil {
-			http.Error(w, "Ошибка при создании пользователя: "+err.Error(), http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "CreateUser failed", "upstream", "users", "error", err)
+			writeUpstreamError(w, r, ctx, err, apierror.MsgCreateUserFailed)
 			return
 		}
 
 		// Отправка успешного ответа
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(resp)
+		json.NewEncoder(w).Encode(dto.CreateUserFromProto(resp))
 	}
 }
@@ -9,7 +9,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"messenger_frontend/internal/handlers"
+	"messenger_frontend/internal/middleware"
+	"messenger_frontend/internal/openapi/openapitest"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -59,6 +63,7 @@ func TestCreateUserHandler_Success(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	handler.CreateUserHandler().ServeHTTP(w, r)
+	openapitest.Check(t, r, w)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), `"success":"99"`)
@@ -86,6 +91,7 @@ func TestLoginHandler_Success(t *testing.T) {
 	r := httptest.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer(body))
 	w := httptest.NewRecorder()
 	handler.LoginHandler().ServeHTTP(w, r)
+	openapitest.Check(t, r, w)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "token123")
@@ -114,6 +120,7 @@ func TestGetUserHandler_ByLogin_Success(t *testing.T) {
 	r := httptest.NewRequest(http.MethodGet, "/users/get?login=user1", nil)
 	w := httptest.NewRecorder()
 	handler.GetUserHandler().ServeHTTP(w, r)
+	openapitest.Check(t, r, w)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), `"login":"user1"`)
@@ -125,9 +132,10 @@ func TestGetUserHandler_NoParams(t *testing.T) {
 	r := httptest.NewRequest(http.MethodGet, "/users/get", nil)
 	w := httptest.NewRecorder()
 	handler.GetUserHandler().ServeHTTP(w, r)
+	openapitest.Check(t, r, w)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Contains(t, w.Body.String(), "нужно указать хотя бы один параметр")
+	assert.Contains(t, w.Body.String(), `"code":"search_params_required"`)
 }
 
 func TestLoginHandler_InvalidJSON(t *testing.T) {
@@ -136,17 +144,75 @@ func TestLoginHandler_InvalidJSON(t *testing.T) {
 	r := httptest.NewRequest(http.MethodPost, "/users/login", bytes.NewBufferString("{invalid"))
 	w := httptest.NewRecorder()
 	handler.LoginHandler().ServeHTTP(w, r)
+	openapitest.Check(t, r, w)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Contains(t, w.Body.String(), "не удалось разобрать тело запроса")
+	assert.Contains(t, w.Body.String(), `"code":"invalid_body"`)
 }
 
 func TestCreateUserHandler_InvalidMethod(t *testing.T) {
 	handler := handlers.NewUserHandlerService(nil, nil)
+	mux := http.NewServeMux()
+	handler.RegisterHandlers(mux)
 
+	// Метод проверяет mux по шаблону маршрута, ответ — в формате ошибок API
 	r := httptest.NewRequest(http.MethodGet, "/users/create", nil)
 	w := httptest.NewRecorder()
-	handler.CreateUserHandler().ServeHTTP(w, r)
+	middleware.MuxErrorsMiddleware(mux).ServeHTTP(w, r)
 
 	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "POST", w.Header().Get("Allow"))
+	assert.Contains(t, w.Body.String(), `"code":"method_not_allowed"`)
+}
+
+func TestGetUserByIDHandler_NotFound(t *testing.T) {
+	mockClient := new(mockUserServiceClient)
+	handler := handlers.NewUserHandlerService(mockClient, nil)
+	mux := http.NewServeMux()
+	handler.RegisterHandlers(mux)
+
+	mockClient.On("GetUser", mock.Anything, &messenger_users_api.GetUserRequest{
+		Id: ptr(int64(7)),
+	}).Return(&messenger_users_api.GetUserResponse{}, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/users/7", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	openapitest.Check(t, r, w)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Пользователь не найден")
+}
+
+func TestCreateUserHandler_AlreadyExists(t *testing.T) {
+	mockClient := new(mockUserServiceClient)
+	handler := handlers.NewUserHandlerService(mockClient, nil)
+
+	mockClient.On("CreateUser", mock.Anything, mock.Anything).
+		Return((*messenger_users_api.CreateResponse)(nil), status.Error(codes.AlreadyExists, "login already taken"))
+
+	r := httptest.NewRequest(http.MethodPost, "/users/create", bytes.NewBufferString(`{"login":"user1"}`))
+	w := httptest.NewRecorder()
+	handler.CreateUserHandler().ServeHTTP(w, r)
+	openapitest.Check(t, r, w)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	// Текст upstream не передаётся, сообщение берётся из каталога
+	assert.JSONEq(t, `{"code":"already_exists","message":"Такой объект уже существует"}`, w.Body.String())
+}
+
+func TestCreateUserHandler_InternalErrorNotLeaked(t *testing.T) {
+	mockClient := new(mockUserServiceClient)
+	handler := handlers.NewUserHandlerService(mockClient, nil)
+
+	mockClient.On("CreateUser", mock.Anything, mock.Anything).
+		Return((*messenger_users_api.CreateResponse)(nil), status.Error(codes.Internal, "pq: relation users does not exist"))
+
+	r := httptest.NewRequest(http.MethodPost, "/users/create", bytes.NewBufferString(`{"login":"user1"}`))
+	w := httptest.NewRecorder()
+	handler.CreateUserHandler().ServeHTTP(w, r)
+	openapitest.Check(t, r, w)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "pq:")
 }
@@ -0,0 +1,196 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/connectivity"
+
+	"messenger_frontend/internal/lifecycle"
+	"messenger_frontend/internal/router"
+)
+
+// Probe проверяет доступность одной зависимости.
+type Probe func(ctx context.Context) error
+
+type check struct {
+	name     string
+	critical bool
+	probe    Probe
+}
+
+// Checker отвечает на liveness и readiness пробы оркестратора. Шлюз не готов,
+// если упала хотя бы одна критичная зависимость или началась остановка;
+// падение некритичной зависимости отражается только в статусе "degraded".
+type Checker struct {
+	drainer *lifecycle.Drainer
+	timeout time.Duration
+	checks  []check
+}
+
+func NewChecker(drainer *lifecycle.Drainer, timeout time.Duration) *Checker {
+	return &Checker{drainer: drainer, timeout: timeout}
+}
+
+func (c *Checker) Add(name string, critical bool, probe Probe) {
+	c.checks = append(c.checks, check{name: name, critical: critical, probe: probe})
+}
+
+func (c *Checker) RegisterHandlers(mux router.Mux) {
+	mux.HandleFunc("/healthz", c.LivenessHandler())
+	mux.HandleFunc("/readyz", c.ReadinessHandler())
+}
+
+// LivenessHandler сообщает только о том, что процесс жив и обслуживает HTTP.
+func (c *Checker) LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	}
+}
+
+// DependencyStatus — состояние зависимости в ответе /readyz. Текст ошибки сюда не попадает:
+// /readyz открыт наружу, а ошибки подключения содержат внутренние адреса. Он пишется в лог.
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+type Report struct {
+	Status       string                      `json:"status"`
+	Draining     bool                        `json:"draining"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+func (c *Checker) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := c.Check(r.Context())
+		code := http.StatusOK
+		if resp.Status == "not_ready" {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, resp)
+	}
+}
+
+// Check опрашивает все зависимости параллельно, каждую не дольше timeout.
+func (c *Checker) Check(ctx context.Context) Report {
+	resp := Report{
+		Status:       "ready",
+		Dependencies: make(map[string]DependencyStatus, len(c.checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, chk := range c.checks {
+		wg.Add(1)
+		go func(chk check) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := chk.probe(probeCtx)
+			st := DependencyStatus{
+				Status:    "up",
+				Critical:  chk.critical,
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				st.Status = "down"
+				slog.WarnContext(ctx, "readiness probe failed",
+					"dependency", chk.name, "critical", chk.critical, "error", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Dependencies[chk.name] = st
+		}(chk)
+	}
+	wg.Wait()
+
+	for _, st := range resp.Dependencies {
+		if st.Status == "up" {
+			continue
+		}
+		if st.Critical {
+			resp.Status = "not_ready"
+		} else if resp.Status == "ready" {
+			resp.Status = "degraded"
+		}
+	}
+	if c.drainer != nil && c.drainer.Draining() {
+		resp.Draining = true
+		resp.Status = "not_ready"
+	}
+	return resp
+}
+
+func RedisProbe(rdb redis.UniversalClient) Probe {
+	return func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	}
+}
+
+// StateConn — часть *grpc.ClientConn, нужная для проверки состояния подключения.
+type StateConn interface {
+	GetState() connectivity.State
+	Connect()
+	WaitForStateChange(ctx context.Context, sourceState connectivity.State) bool
+}
+
+// GRPCProbe проверяет состояние соединения без вызова RPC. Соединение в Idle
+// считается рабочим: grpc переводит его туда после простоя, и проба инициирует переподключение.
+func GRPCProbe(conn StateConn) Probe {
+	return func(ctx context.Context) error {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Idle:
+			conn.Connect()
+			return nil
+		case connectivity.Connecting:
+			// Дожидаемся результата подключения в пределах таймаута пробы.
+			if conn.WaitForStateChange(ctx, state) && conn.GetState() == connectivity.Ready {
+				return nil
+			}
+		}
+		return fmt.Errorf("connection state %s", conn.GetState())
+	}
+}
+
+// HTTPProbe считает upstream доступным, если он отвечает без ошибки 5xx. Адрес берётся из url
+// при каждой проверке, чтобы проба следовала за перезагрузкой конфигурации.
+func HTTPProbe(client *http.Client, url func() string) Probe {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
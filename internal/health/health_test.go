@@ -0,0 +1,99 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"messenger_frontend/internal/lifecycle"
+)
+
+func ok(context.Context) error   { return nil }
+func fail(context.Context) error { return errors.New("boom") }
+
+func readiness(t *testing.T, c *Checker) (int, Report) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	c.ReadinessHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	// Текст ошибок зависимостей наружу не отдаётся
+	assert.NotContains(t, rr.Body.String(), "boom")
+	var resp Report
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	return rr.Code, resp
+}
+
+func TestReadiness_CriticalFailure(t *testing.T) {
+	c := NewChecker(lifecycle.NewDrainer(), time.Second)
+	c.Add("redis", true, ok)
+	c.Add("dialogs", true, fail)
+
+	code, resp := readiness(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", resp.Status)
+	assert.Equal(t, "down", resp.Dependencies["dialogs"].Status)
+	assert.Equal(t, "up", resp.Dependencies["redis"].Status)
+}
+
+func TestReadiness_NonCriticalFailureIsDegraded(t *testing.T) {
+	c := NewChecker(lifecycle.NewDrainer(), time.Second)
+	c.Add("redis", true, ok)
+	c.Add("notifications", false, fail)
+
+	code, resp := readiness(t, c)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "degraded", resp.Status)
+}
+
+func TestReadiness_Draining(t *testing.T) {
+	drainer := lifecycle.NewDrainer()
+	c := NewChecker(drainer, time.Second)
+	c.Add("redis", true, ok)
+	drainer.Drain()
+
+	code, resp := readiness(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.True(t, resp.Draining)
+}
+
+func TestRedisProbe(t *testing.T) {
+	rdb, mock := redismock.NewClientMock()
+	mock.ExpectPing().SetVal("PONG")
+	assert.NoError(t, RedisProbe(rdb)(context.Background()))
+
+	mock.ExpectPing().SetErr(errors.New("connection refused"))
+	assert.Error(t, RedisProbe(rdb)(context.Background()))
+}
+
+func TestHTTPProbe_FollowsURL(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer healthy.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	url := broken.URL
+	probe := HTTPProbe(http.DefaultClient, func() string { return url })
+	assert.Error(t, probe(context.Background()))
+
+	// После перезагрузки конфигурации проба обращается по новому адресу
+	url = healthy.URL
+	assert.NoError(t, probe(context.Background()))
+}
+
+func TestLiveness(t *testing.T) {
+	c := NewChecker(nil, time.Second)
+	c.Add("dialogs", true, fail)
+
+	rr := httptest.NewRecorder()
+	c.LivenessHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,94 @@
+package i18n
+
+// catalog содержит тексты сообщений API по языкам. Ключи — коды ошибок и ключи сообщений
+// из пакета apierror; при добавлении ключа нужен перевод на все языки из Supported.
+var catalog = map[string]map[string]string{
+	RU: {
+		"invalid_argument":    "Некорректный запрос",
+		"unauthenticated":     "Требуется авторизация",
+		"permission_denied":   "Недостаточно прав",
+		"not_found":           "Не найдено",
+		"method_not_allowed":  "Метод не поддерживается",
+		"already_exists":      "Такой объект уже существует",
+		"failed_precondition": "Операция невозможна в текущем состоянии",
+		"aborted":             "Операция прервана из-за конфликта, повторите запрос",
+		"out_of_range":        "Значение вне допустимого диапазона",
+		"resource_exhausted":  "Слишком много запросов, повторите позже",
+		"canceled":            "Запрос отменён",
+		"unimplemented":       "Операция не поддерживается",
+		"internal":            "Внутренняя ошибка сервера",
+		"bad_gateway":         "Сервис вернул некорректный ответ",
+		"unavailable":         "Сервис временно недоступен",
+		"deadline_exceeded":   "Превышено время ожидания ответа сервиса",
+
+		"invalid_body":              "Неправильный формат тела запроса",
+		"token_missing":             "Требуется токен авторизации",
+		"token_invalid":             "Недействительный токен",
+		"invalid_credentials":       "Некорректный логин или пароль",
+		"credentials_required":      "login и password обязательны",
+		"user_id_invalid":           "id должен быть положительным числом",
+		"user_not_found":            "Пользователь не найден",
+		"search_params_required":    "Нужно указать хотя бы один параметр поиска",
+		"dialog_id_required":        "dialog_id обязателен",
+		"dialog_id_invalid":         "dialog_id должен быть числом",
+		"message_fields_required":   "dialog_id и text обязательны",
+		"paging_invalid":            "Неверные параметры страницы: limit, offset или cursor",
+		"overloaded":                "Сервер перегружен, повторите запрос позже",
+		"notifications_unavailable": "Сервис уведомлений недоступен",
+		"server_restarting":         "Сервер перезапускается, переподключитесь",
+		"reload_rejected":           "Конфигурация отклонена, подробности в логе шлюза",
+
+		"create_dialog_failed": "Не удалось создать диалог",
+		"send_message_failed":  "Не удалось отправить сообщение",
+		"get_dialogs_failed":   "Не удалось получить список диалогов",
+		"get_messages_failed":  "Не удалось получить сообщения",
+		"get_user_failed":      "Не удалось получить пользователя",
+		"login_failed":         "Ошибка сервера при входе",
+		"create_user_failed":   "Не удалось создать пользователя",
+		"token_save_failed":    "Не удалось сохранить токен",
+	},
+	EN: {
+		"invalid_argument":    "Invalid request",
+		"unauthenticated":     "Authentication required",
+		"permission_denied":   "Permission denied",
+		"not_found":           "Not found",
+		"method_not_allowed":  "Method not allowed",
+		"already_exists":      "Already exists",
+		"failed_precondition": "Operation is not possible in the current state",
+		"aborted":             "Operation aborted due to a conflict, please retry",
+		"out_of_range":        "Value is out of range",
+		"resource_exhausted":  "Too many requests, please retry later",
+		"canceled":            "Request canceled",
+		"unimplemented":       "Operation not supported",
+		"internal":            "Internal server error",
+		"bad_gateway":         "Upstream service returned an invalid response",
+		"unavailable":         "Service temporarily unavailable",
+		"deadline_exceeded":   "Upstream service timed out",
+
+		"invalid_body":              "Malformed request body",
+		"token_missing":             "Authorization token required",
+		"token_invalid":             "Invalid token",
+		"invalid_credentials":       "Invalid login or password",
+		"credentials_required":      "login and password are required",
+		"user_id_invalid":           "id must be a positive number",
+		"user_not_found":            "User not found",
+		"search_params_required":    "At least one search parameter is required",
+		"dialog_id_required":        "dialog_id is required",
+		"dialog_id_invalid":         "dialog_id must be a number",
+		"message_fields_required":   "dialog_id and text are required",
+		"paging_invalid":            "Invalid paging parameters: limit, offset or cursor",
+		"overloaded":                "Server is overloaded, please retry later",
+		"notifications_unavailable": "Notification service unavailable",
+		"server_restarting":         "Server is restarting, please reconnect",
+		"reload_rejected":           "Configuration rejected, see the gateway log",
+
+		"create_dialog_failed": "Failed to create dialog",
+		"send_message_failed":  "Failed to send message",
+		"get_dialogs_failed":   "Failed to load dialogs",
+		"get_messages_failed":  "Failed to load messages",
+		"get_user_failed":      "Failed to load user",
+		"login_failed":         "Login failed due to a server error",
+		"create_user_failed":   "Failed to create user",
+		"token_save_failed":    "Failed to save token",
+	},
+}
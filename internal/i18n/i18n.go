@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Поддерживаемые языки ответов.
+const (
+	RU = "ru"
+	EN = "en"
+)
+
+// Default используется, если клиент не указал ни один поддерживаемый язык.
+const Default = RU
+
+// PreferenceCookie хранит выбранный пользователем язык; он важнее Accept-Language.
+const PreferenceCookie = "lang"
+
+var Supported = []string{RU, EN}
+
+// T возвращает текст сообщения key на языке locale. Если перевода нет, используется язык
+// по умолчанию, а в крайнем случае сам ключ.
+func T(locale, key string) string {
+	if msg, ok := catalog[locale][key]; ok {
+		return msg
+	}
+	if msg, ok := catalog[Default][key]; ok {
+		return msg
+	}
+	return key
+}
+
+// Has сообщает, есть ли у key перевод на язык locale.
+func Has(locale, key string) bool {
+	_, ok := catalog[locale][key]
+	return ok
+}
+
+// Locale выбирает язык ответа: сначала cookie lang, затем Accept-Language.
+func Locale(r *http.Request) string {
+	if c, err := r.Cookie(PreferenceCookie); err == nil {
+		if l := base(c.Value); slices.Contains(Supported, l) {
+			return l
+		}
+	}
+	return Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// Negotiate выбирает из заголовка Accept-Language поддерживаемый язык с наибольшим весом q.
+// Региональные варианты (en-US) сводятся к базовому языку.
+func Negotiate(header string) string {
+	best, bestQ := Default, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		l := base(tag)
+		if q > bestQ && slices.Contains(Supported, l) {
+			best, bestQ = l, q
+		}
+	}
+	return best
+}
+
+func base(tag string) string {
+	l, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	return l
+}
@@ -0,0 +1,53 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog_LocalesHaveSameKeys(t *testing.T) {
+	for key := range catalog[Default] {
+		for _, locale := range Supported {
+			assert.True(t, Has(locale, key), "no %q translation for %q", locale, key)
+		}
+	}
+	for _, locale := range Supported {
+		assert.Len(t, catalog[locale], len(catalog[Default]), locale)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := map[string]string{
+		"":                        RU,
+		"en":                      EN,
+		"en-US,en;q=0.9":          EN,
+		"de-DE,en;q=0.8,ru;q=0.9": RU,
+		"fr, *;q=0.5":             RU,
+		"ru;q=0, en;q=0.1":        EN,
+		"en;q=bogus, ru":          RU,
+	}
+	for header, want := range tests {
+		assert.Equal(t, want, Negotiate(header), header)
+	}
+}
+
+func TestLocale_PreferenceCookie(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "ru")
+	r.AddCookie(&http.Cookie{Name: PreferenceCookie, Value: "en"})
+	assert.Equal(t, EN, Locale(r))
+
+	// Неподдерживаемый язык в cookie не мешает Accept-Language
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "en")
+	r.AddCookie(&http.Cookie{Name: PreferenceCookie, Value: "de"})
+	assert.Equal(t, EN, Locale(r))
+}
+
+func TestT_FallsBackToDefault(t *testing.T) {
+	assert.Equal(t, catalog[RU]["internal"], T("de", "internal"))
+	assert.Equal(t, "unknown_key", T(EN, "unknown_key"))
+}
@@ -4,16 +4,21 @@ import (
 	"errors"
 	"github.com/golang-jwt/jwt/v5"
 	"os"
+	"sync/atomic"
 )
 
-var jwtSecret = []byte(os.Getenv("SECRETKEY"))
+var jwtSecret atomic.Pointer[[]byte]
+
+func init() {
+	SetSecret([]byte(os.Getenv("SECRETKEY")))
+}
 
 func ValidateToken(tokenString string) (string, error) {
 	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return jwtSecret, nil
+		return *jwtSecret.Load(), nil
 	})
 	if err != nil || !token.Valid {
 		return "", errors.New("invalid token")
@@ -31,3 +36,9 @@ func ValidateToken(tokenString string) (string, error) {
 
 	return userID, nil
 }
+
+// SetSecret заменяет ключ подписи, прочитанный из SECRETKEY при старте.
+// Безопасен для вызова во время обработки запросов.
+func SetSecret(secret []byte) {
+	jwtSecret.Store(&secret)
+}
@@ -0,0 +1,35 @@
+package lifecycle
+
+import "context"
+
+// Drainer сообщает компонентам шлюза, что началась остановка: readiness должна
+// провалиться, а долгие запросы — завершиться досрочно.
+type Drainer struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewDrainer() *Drainer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Drainer{ctx: ctx, cancel: cancel}
+}
+
+// Drain переводит шлюз в режим остановки. Повторные вызовы ничего не делают.
+func (d *Drainer) Drain() {
+	d.cancel()
+}
+
+// Done закрывается при переходе в режим остановки.
+func (d *Drainer) Done() <-chan struct{} {
+	return d.ctx.Done()
+}
+
+// Context отменяется при переходе в режим остановки; на нём держится фоновая работа,
+// которая не должна переживать шлюз.
+func (d *Drainer) Context() context.Context {
+	return d.ctx
+}
+
+func (d *Drainer) Draining() bool {
+	return d.ctx.Err() != nil
+}
@@ -0,0 +1,188 @@
+package limiter
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Priority — класс приоритета запроса. Чем выше значение, тем позже запросы класса отбрасываются.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	}
+	return "unknown"
+}
+
+// Outcome — результат обработки запроса, по которому подстраивается лимит.
+type Outcome int
+
+const (
+	// OutcomeSuccess — запрос обработан, лимит может расти.
+	OutcomeSuccess Outcome = iota
+	// OutcomeDropped — upstream не справился (таймаут, перегрузка), лимит уменьшается.
+	OutcomeDropped
+	// OutcomeIgnore — запрос не влияет на лимит.
+	OutcomeIgnore
+)
+
+type Config struct {
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	// TargetLatency — задержка, превышение которой считается признаком перегрузки.
+	TargetLatency time.Duration
+	// BackoffRatio — множитель, на который уменьшается лимит при перегрузке.
+	BackoffRatio float64
+	// Ceilings — доля лимита, до которой класс может занимать общую ёмкость.
+	Ceilings map[Priority]float64
+	// MinShares — доля лимита, гарантированная классу даже при заполненной ёмкости.
+	MinShares map[Priority]float64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		InitialLimit:  100,
+		MinLimit:      10,
+		MaxLimit:      1000,
+		TargetLatency: time.Second,
+		BackoffRatio:  0.9,
+		Ceilings: map[Priority]float64{
+			PriorityHigh:   1.0,
+			PriorityNormal: 0.8,
+			PriorityLow:    0.5,
+		},
+		MinShares: map[Priority]float64{
+			PriorityHigh:   0.2,
+			PriorityNormal: 0.1,
+			PriorityLow:    0.05,
+		},
+	}
+}
+
+// Limiter ограничивает число одновременно обрабатываемых запросов.
+// Лимит подстраивается по схеме AIMD: растёт на единицу при успешных быстрых ответах
+// и умножается на BackoffRatio при таймаутах или задержке выше TargetLatency.
+type Limiter struct {
+	mu       sync.Mutex
+	cfg      Config
+	limit    float64
+	inflight int
+	byClass  map[Priority]int
+}
+
+func New(cfg Config) *Limiter {
+	cfg = normalize(cfg)
+	return &Limiter{
+		cfg:     cfg,
+		limit:   clamp(float64(cfg.InitialLimit), cfg),
+		byClass: make(map[Priority]int),
+	}
+}
+
+func normalize(cfg Config) Config {
+	if cfg.MinLimit <= 0 {
+		cfg.MinLimit = 1
+	}
+	if cfg.MaxLimit < cfg.MinLimit {
+		cfg.MaxLimit = cfg.MinLimit
+	}
+	if cfg.BackoffRatio <= 0 || cfg.BackoffRatio >= 1 {
+		cfg.BackoffRatio = 0.9
+	}
+	return cfg
+}
+
+func clamp(limit float64, cfg Config) float64 {
+	limit = math.Max(limit, float64(cfg.MinLimit))
+	return math.Min(limit, float64(cfg.MaxLimit))
+}
+
+// Reconfigure применяет новые параметры, сохраняя набранный лимит в новых границах.
+// Запросы в обработке не затрагиваются.
+func (l *Limiter) Reconfigure(cfg Config) {
+	cfg = normalize(cfg)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+	l.limit = clamp(l.limit, cfg)
+}
+
+// Token удерживает слот до вызова Release.
+type Token struct {
+	l        *Limiter
+	priority Priority
+	start    time.Time
+}
+
+// Acquire занимает слот для запроса класса p. Если ёмкости нет, возвращает false.
+// Класс допускается, пока общая загрузка ниже его доли Ceilings или пока сам класс
+// занимает меньше гарантированной доли MinShares.
+func (l *Limiter) Acquire(p Priority) (*Token, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ceiling := l.cfg.Ceilings[p]
+	if ceiling == 0 {
+		ceiling = 1
+	}
+	underCeiling := float64(l.inflight) < l.limit*ceiling
+	underMinShare := float64(l.byClass[p]) < math.Max(1, l.limit*l.cfg.MinShares[p])
+	if !underCeiling && !underMinShare {
+		return nil, false
+	}
+
+	l.inflight++
+	l.byClass[p]++
+	return &Token{l: l, priority: p, start: time.Now()}, true
+}
+
+// Release освобождает слот и обновляет лимит по результату запроса.
+func (t *Token) Release(outcome Outcome) {
+	l := t.l
+	latency := time.Since(t.start)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inflight := l.inflight
+	l.inflight--
+	l.byClass[t.priority]--
+
+	switch {
+	case outcome == OutcomeIgnore:
+	case outcome == OutcomeDropped || (l.cfg.TargetLatency > 0 && latency > l.cfg.TargetLatency):
+		l.limit = math.Max(float64(l.cfg.MinLimit), l.limit*l.cfg.BackoffRatio)
+	case float64(inflight)*2 >= l.limit:
+		// Растём только при заметной загрузке, иначе лимит уйдёт в потолок без нагрузки.
+		l.limit = math.Min(float64(l.cfg.MaxLimit), l.limit+1)
+	}
+}
+
+// Limit возвращает текущий лимит.
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// InFlight возвращает число запросов в обработке.
+func (l *Limiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight
+}
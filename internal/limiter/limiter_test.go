@@ -0,0 +1,105 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig(limit int) Config {
+	cfg := DefaultConfig()
+	cfg.InitialLimit = limit
+	cfg.MinLimit = 1
+	cfg.MaxLimit = 100
+	return cfg
+}
+
+func TestLimiter_ShedsLowPriorityFirst(t *testing.T) {
+	l := New(testConfig(10))
+
+	var tokens []*Token
+	for i := 0; i < 5; i++ {
+		tok, ok := l.Acquire(PriorityLow)
+		assert.True(t, ok)
+		tokens = append(tokens, tok)
+	}
+	// Низкий приоритет упирается в свою долю (50%), высокий продолжает проходить.
+	_, ok := l.Acquire(PriorityLow)
+	assert.False(t, ok)
+	for i := 0; i < 5; i++ {
+		tok, ok := l.Acquire(PriorityHigh)
+		assert.True(t, ok)
+		tokens = append(tokens, tok)
+	}
+	// Ёмкость для normal исчерпана, остаётся только его гарантированная доля (10% — один слот).
+	tok, ok := l.Acquire(PriorityNormal)
+	assert.True(t, ok)
+	tokens = append(tokens, tok)
+	_, ok = l.Acquire(PriorityNormal)
+	assert.False(t, ok)
+
+	for _, tok := range tokens {
+		tok.Release(OutcomeIgnore)
+	}
+	assert.Equal(t, 0, l.InFlight())
+}
+
+func TestLimiter_MinShareAdmitsStarvedClass(t *testing.T) {
+	cfg := testConfig(10)
+	cfg.MinShares[PriorityNormal] = 0.2
+	l := New(cfg)
+
+	for i := 0; i < 10; i++ {
+		_, ok := l.Acquire(PriorityHigh)
+		assert.True(t, ok)
+	}
+	_, ok := l.Acquire(PriorityHigh)
+	assert.False(t, ok)
+
+	// Класс normal получает гарантированные 20% лимита, даже когда ёмкость занята.
+	_, ok = l.Acquire(PriorityNormal)
+	assert.True(t, ok)
+	_, ok = l.Acquire(PriorityNormal)
+	assert.True(t, ok)
+	_, ok = l.Acquire(PriorityNormal)
+	assert.False(t, ok)
+}
+
+func TestLimiter_AIMD(t *testing.T) {
+	l := New(testConfig(10))
+
+	var tokens []*Token
+	for i := 0; i < 6; i++ {
+		tok, _ := l.Acquire(PriorityHigh)
+		tokens = append(tokens, tok)
+	}
+	tokens[0].Release(OutcomeSuccess)
+	assert.Equal(t, 11, l.Limit())
+
+	tokens[1].Release(OutcomeDropped)
+	assert.Equal(t, 9, l.Limit())
+
+	tokens[2].Release(OutcomeIgnore)
+	assert.Equal(t, 9, l.Limit())
+}
+
+func TestLimiter_SlowResponseDecreasesLimit(t *testing.T) {
+	cfg := testConfig(10)
+	cfg.TargetLatency = time.Millisecond
+	l := New(cfg)
+
+	tok, _ := l.Acquire(PriorityHigh)
+	time.Sleep(5 * time.Millisecond)
+	tok.Release(OutcomeSuccess)
+	assert.Equal(t, 9, l.Limit())
+}
+
+func TestLimiter_ReconfigureClampsLimit(t *testing.T) {
+	l := New(testConfig(50))
+
+	cfg := testConfig(10)
+	cfg.MaxLimit = 20
+	l.Reconfigure(cfg)
+	assert.Equal(t, 20, l.Limit())
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Breakdown накапливает время, которое запрос провёл в upstream-сервисах и Redis,
+// чтобы журнал доступа показывал, куда ушла длительность запроса.
+type Breakdown struct {
+	mu    sync.Mutex
+	spent map[string]time.Duration
+}
+
+type breakdownKey struct{}
+
+// WithBreakdown добавляет к контексту пустой Breakdown.
+func WithBreakdown(ctx context.Context) (context.Context, *Breakdown) {
+	b := &Breakdown{spent: make(map[string]time.Duration)}
+	return context.WithValue(ctx, breakdownKey{}, b), b
+}
+
+// ObserveUpstream прибавляет d ко времени upstream name. Без Breakdown в контексте ничего не делает.
+func ObserveUpstream(ctx context.Context, name string, d time.Duration) {
+	b, ok := ctx.Value(breakdownKey{}).(*Breakdown)
+	if !ok {
+		return
+	}
+	b.mu.Lock()
+	b.spent[name] += d
+	b.mu.Unlock()
+}
+
+// Spent возвращает копию накопленных длительностей.
+func (b *Breakdown) Spent() map[string]time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make(map[string]time.Duration, len(b.spent))
+	for k, v := range b.spent {
+		out[k] = v
+	}
+	return out
+}
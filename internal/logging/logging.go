@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys — части имён полей, значения которых никогда не попадают в лог.
+var sensitiveKeys = []string{"token", "password", "passwd", "secret", "authorization", "cookie", "api_key", "apikey"}
+
+var scrubbers = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`), "Bearer " + redacted},
+	{regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), redacted},
+	{regexp.MustCompile(`(?i)\b(password|passwd|token|secret)(["']?\s*[:=]\s*["']?)[^\s&"',}]+`), "${1}${2}" + redacted},
+}
+
+// New возвращает JSON-логгер уровня level. Секреты скрываются и по имени поля, и по содержимому
+// строк (Bearer-токены, JWT, password=...), а поля из контекста запроса добавляются к каждой записи.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: redact})
+	return slog.New(&contextHandler{Handler: h})
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scrub заменяет в строке всё, что похоже на токен или пароль.
+func Scrub(s string) string {
+	for _, sc := range scrubbers {
+		s = sc.re.ReplaceAllString(s, sc.repl)
+	}
+	return s
+}
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if isSensitiveKey(a.Key) && a.Value.Kind() != slog.KindGroup {
+		return slog.String(a.Key, redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Scrub(a.Value.String()))
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case http.Header:
+			return slog.Any(a.Key, redactHeader(v))
+		case error:
+			return slog.String(a.Key, Scrub(v.Error()))
+		}
+	}
+	return a
+}
+
+func redactHeader(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, vv := range h {
+		if isSensitiveKey(k) || strings.EqualFold(k, "X-Admin-Token") {
+			out[k] = []string{redacted}
+			continue
+		}
+		scrubbed := make([]string, len(vv))
+		for i, v := range vv {
+			scrubbed[i] = Scrub(v)
+		}
+		out[k] = scrubbed
+	}
+	return out
+}
+
+type fieldsKey struct{}
+
+type requestIDKey struct{}
+
+// With возвращает контекст, записи с которым получат поля args (пары ключ-значение, как в slog).
+func With(ctx context.Context, args ...any) context.Context {
+	prev, _ := ctx.Value(fieldsKey{}).([]any)
+	fields := make([]any, 0, len(prev)+len(args))
+	fields = append(append(fields, prev...), args...)
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// WithRequestID сохраняет идентификатор запроса в контексте и добавляет его к логам.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return With(ctx, "request_id", id)
+}
+
+// RequestID возвращает идентификатор запроса или пустую строку.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler дописывает к записи поля, сохранённые через With.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if fields, ok := ctx.Value(fieldsKey{}).([]any); ok {
+		r.Add(fields...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
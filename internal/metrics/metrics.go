@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gateway"
+
+// Registry содержит метрики шлюза, Go runtime и процесса. Отдельный реестр вместо
+// prometheus.DefaultRegisterer не даёт зависимостям незаметно добавлять свои метрики.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+func register[T prometheus.Collector](c T) T {
+	Registry.MustRegister(c)
+	return c
+}
+
+// Корзины гистограмм: от быстрых ответов из кэша до long-poll, который держится десятки секунд.
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+var (
+	HTTPRequests = register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route pattern, method and status.",
+	}, []string{"route", "method", "status"}))
+
+	HTTPRequestDuration = register(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route pattern, method and status.",
+		Buckets:   latencyBuckets,
+	}, []string{"route", "method", "status"}))
+
+	HTTPInFlight = register(prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	}, []string{"route"}))
+
+	GRPCClientRequests = register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_client_requests_total",
+		Help:      "gRPC calls to upstream services by service, method and status code.",
+	}, []string{"service", "method", "code"}))
+
+	GRPCClientDuration = register(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_client_request_duration_seconds",
+		Help:      "gRPC call latency to upstream services by service, method and status code.",
+		Buckets:   latencyBuckets,
+	}, []string{"service", "method", "code"}))
+
+	RedisCommandDuration = register(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Redis command latency by command and result (ok, miss, timeout, error).",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command", "result"}))
+
+	Logins = register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by result (success, failure, error).",
+	}, []string{"result"}))
+
+	MessagesSent = register(prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_sent_total",
+		Help:      "Messages successfully sent through the gateway.",
+	}))
+
+	DialogsCreated = register(prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dialogs_created_total",
+		Help:      "Dialogs successfully created through the gateway.",
+	}))
+)
+
+// RegisterGaugeFunc добавляет показатель, значение которого вычисляется при каждом сборе метрик.
+func RegisterGaugeFunc(name, help string, f func() float64) {
+	Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, f))
+}
+
+// Handler отдаёт метрики в формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"messenger_frontend/internal/logging"
+)
+
+// Форматы журнала доступа.
+const (
+	AccessLogCommon   = "common"
+	AccessLogCombined = "combined"
+	AccessLogJSON     = "json"
+)
+
+// AccessLogSampling решает, какая доля запросов попадает в журнал доступа.
+type AccessLogSampling interface {
+	SampleRate(route string) float64
+	SlowThreshold() time.Duration
+}
+
+// AccessLogRules — доля записываемых запросов, общая и для отдельных маршрутов, и порог медленного запроса.
+type AccessLogRules struct {
+	Default float64
+	Routes  map[string]float64
+	Slow    time.Duration
+}
+
+func (r AccessLogRules) SampleRate(route string) float64 {
+	if rate, ok := r.Routes[route]; ok {
+		return rate
+	}
+	return r.Default
+}
+
+func (r AccessLogRules) SlowThreshold() time.Duration {
+	return r.Slow
+}
+
+type accessUserKey struct{}
+
+// setAccessUser сообщает журналу доступа пользователя: JWTAuthMiddleware стоит глубже
+// и свой контекст наружу не возвращает.
+func setAccessUser(ctx context.Context, userID string) {
+	if p, ok := ctx.Value(accessUserKey{}).(*string); ok {
+		*p = userID
+	}
+}
+
+// AccessLogMiddleware пишет строку на каждый запрос в формате common, combined или json.
+// Запросы медленнее порога и ответы 5xx пишутся всегда, остальные — с долей из sampling.
+// К записи добавляется время, проведённое в upstream-сервисах и Redis.
+func AccessLogMiddleware(out io.Writer, format string, sampling AccessLogSampling, route func(*http.Request) string, next http.Handler) http.Handler {
+	var mu sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, breakdown := logging.WithBreakdown(r.Context())
+		var userID string
+		ctx = context.WithValue(ctx, accessUserKey{}, &userID)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		elapsed := time.Since(start)
+
+		pattern := route(r)
+		if pattern == "" {
+			pattern = UnmatchedRoute
+		}
+		slow := sampling.SlowThreshold() > 0 && elapsed >= sampling.SlowThreshold()
+		if !slow && rec.status < http.StatusInternalServerError && rand.Float64() >= sampling.SampleRate(pattern) {
+			return
+		}
+
+		e := accessEntry{
+			Time:       start.UTC().Format(time.RFC3339Nano),
+			RemoteAddr: remoteHost(r),
+			Method:     r.Method,
+			URI:        logging.Scrub(r.URL.RequestURI()),
+			Proto:      r.Proto,
+			Route:      pattern,
+			Status:     rec.status,
+			Bytes:      rec.bytes,
+			DurationMS: milliseconds(elapsed),
+			RequestID:  logging.RequestID(ctx),
+			UserID:     userID,
+			Referer:    r.Referer(),
+			UserAgent:  r.UserAgent(),
+			Slow:       slow,
+		}
+		if spent := breakdown.Spent(); len(spent) > 0 {
+			e.UpstreamMS = make(map[string]float64, len(spent))
+			for name, d := range spent {
+				e.UpstreamMS[name] = milliseconds(d)
+			}
+		}
+
+		line := e.format(format, start)
+		mu.Lock()
+		_, _ = out.Write(line)
+		mu.Unlock()
+	})
+}
+
+type accessEntry struct {
+	Time       string             `json:"time"`
+	RemoteAddr string             `json:"remote_addr"`
+	Method     string             `json:"method"`
+	URI        string             `json:"uri"`
+	Proto      string             `json:"proto"`
+	Route      string             `json:"route"`
+	Status     int                `json:"status"`
+	Bytes      int64              `json:"bytes"`
+	DurationMS float64            `json:"duration_ms"`
+	RequestID  string             `json:"request_id,omitempty"`
+	UserID     string             `json:"user_id,omitempty"`
+	Referer    string             `json:"referer,omitempty"`
+	UserAgent  string             `json:"user_agent,omitempty"`
+	UpstreamMS map[string]float64 `json:"upstream_ms,omitempty"`
+	Slow       bool               `json:"slow,omitempty"`
+}
+
+// format собирает строку журнала. Common и combined совпадают с форматами Apache и nginx,
+// а поля шлюза дописываются после них парами key=value.
+func (e accessEntry) format(format string, start time.Time) []byte {
+	if format == AccessLogJSON {
+		data, _ := json.Marshal(e)
+		return append(data, '\n')
+	}
+
+	var b strings.Builder
+	b.WriteString(e.RemoteAddr)
+	b.WriteString(" - ")
+	b.WriteString(orDash(e.UserID))
+	b.WriteString(" [")
+	b.WriteString(start.Format("02/Jan/2006:15:04:05 -0700"))
+	b.WriteString("] ")
+	b.WriteString(strconv.Quote(e.Method + " " + e.URI + " " + e.Proto))
+	b.WriteString(" ")
+	b.WriteString(strconv.Itoa(e.Status))
+	b.WriteString(" ")
+	if e.Bytes > 0 {
+		b.WriteString(strconv.FormatInt(e.Bytes, 10))
+	} else {
+		b.WriteString("-")
+	}
+	if format == AccessLogCombined {
+		b.WriteString(" ")
+		b.WriteString(strconv.Quote(orDash(e.Referer)))
+		b.WriteString(" ")
+		b.WriteString(strconv.Quote(orDash(e.UserAgent)))
+	}
+
+	b.WriteString(" request_id=")
+	b.WriteString(orDash(e.RequestID))
+	b.WriteString(" duration_ms=")
+	b.WriteString(strconv.FormatFloat(e.DurationMS, 'f', -1, 64))
+	if len(e.UpstreamMS) > 0 {
+		names := make([]string, 0, len(e.UpstreamMS))
+		for name := range e.UpstreamMS {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		b.WriteString(" upstream_ms=")
+		for i, name := range names {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(name)
+			b.WriteString(":")
+			b.WriteString(strconv.FormatFloat(e.UpstreamMS[name], 'f', -1, 64))
+		}
+	}
+	if e.Slow {
+		b.WriteString(" slow=true")
+	}
+	b.WriteString("\n")
+	return []byte(b.String())
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// milliseconds округляет длительность до сотых долей миллисекунды.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()/10) / 100
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
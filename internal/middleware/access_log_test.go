@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"messenger_frontend/internal/logging"
+)
+
+func accessLogRoute(r *http.Request) string { return r.URL.Path }
+
+func TestAccessLogMiddleware_JSON(t *testing.T) {
+	var out bytes.Buffer
+	handler := AccessLogMiddleware(&out, AccessLogJSON, AccessLogRules{Default: 1}, accessLogRoute,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			setAccessUser(r.Context(), "42")
+			logging.ObserveUpstream(r.Context(), "dialogs", 12*time.Millisecond)
+			logging.ObserveUpstream(r.Context(), "redis", time.Millisecond)
+			_, _ = w.Write([]byte("hello"))
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/dialog/messages?token=secret123", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var e accessEntry
+	require.NoError(t, json.Unmarshal(out.Bytes(), &e))
+	assert.Equal(t, "/dialog/messages", e.Route)
+	assert.Equal(t, http.StatusOK, e.Status)
+	assert.Equal(t, int64(5), e.Bytes)
+	assert.Equal(t, "42", e.UserID)
+	assert.Equal(t, "test-agent", e.UserAgent)
+	assert.Equal(t, map[string]float64{"dialogs": 12, "redis": 1}, e.UpstreamMS)
+	assert.NotContains(t, e.URI, "secret123")
+}
+
+func TestAccessLogMiddleware_Combined(t *testing.T) {
+	var out bytes.Buffer
+	handler := AccessLogMiddleware(&out, AccessLogCombined, AccessLogRules{Default: 1}, accessLogRoute,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+	req := httptest.NewRequest(http.MethodPost, "/dialog/send", nil)
+	req.Header.Set("Referer", "https://example.com/")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := regexp.MustCompile(`^192\.0\.2\.1 - - \[[^\]]+\] "POST /dialog/send HTTP/1\.1" 204 - "https://example\.com/" "-" request_id=- duration_ms=[0-9.]+\n$`)
+	assert.Regexp(t, line, out.String())
+}
+
+func TestAccessLogMiddleware_Sampling(t *testing.T) {
+	var out bytes.Buffer
+	status := http.StatusOK
+	var delay time.Duration
+	rules := AccessLogRules{Default: 1, Routes: map[string]float64{"/notifications/longpoll": 0}, Slow: 20 * time.Millisecond}
+	handler := AccessLogMiddleware(&out, AccessLogCommon, rules, accessLogRoute,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			w.WriteHeader(status)
+		}))
+	serve := func() {
+		out.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/notifications/longpoll", nil))
+	}
+
+	serve()
+	assert.Empty(t, out.String())
+
+	// Ошибки и медленные запросы пишутся независимо от выборки
+	status = http.StatusBadGateway
+	serve()
+	assert.Contains(t, out.String(), `" 502 `)
+
+	status, delay = http.StatusOK, 25*time.Millisecond
+	serve()
+	assert.Contains(t, out.String(), "slow=true")
+}
@@ -0,0 +1,332 @@
+package middleware
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Кодировки ответов, которые умеет шлюз.
+const (
+	EncodingGzip   = "gzip"
+	EncodingBrotli = "br"
+	EncodingZstd   = "zstd"
+)
+
+// Compression описывает, какие ответы сжимать.
+type Compression struct {
+	// Encodings — кодировки в порядке предпочтения при равных q в Accept-Encoding.
+	Encodings []string
+	// MinSize — ответы короче отдаются как есть: на них сжатие не окупается.
+	MinSize int
+	// ContentTypes — сжимаемые типы без параметров; "text/*" разрешает все подтипы.
+	ContentTypes []string
+	// Exclude — шаблоны маршрутов, ответы которых не сжимаются никогда, например long-poll.
+	Exclude map[string]bool
+}
+
+func (c Compression) allows(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range c.ContentTypes {
+		if t == mediaType || strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(t, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// CompressionMiddleware сжимает ответы кодировкой, выбранной по Accept-Encoding. Ответ
+// копится до MinSize байт: короткий уходит без сжатия, как и ответ, вызвавший Flush раньше
+// порога (потоковый). Сильный ETag сжатого ответа получает суффикс кодировки, а суффикс
+// в If-None-Match снимается, чтобы ConditionalGETMiddleware внутри сравнивал исходные теги.
+func CompressionMiddleware(c Compression, route func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.Exclude[route(r)] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{
+			ResponseWriter: w,
+			config:         &c,
+			encoding:       negotiateEncoding(r.Header.Get("Accept-Encoding"), c.Encodings),
+			status:         http.StatusOK,
+		}
+		if inm := r.Header.Get("If-None-Match"); inm != "" && cw.encoding != "" {
+			if stripped, ok := stripETagSuffix(inm, cw.encoding); ok {
+				r = r.Clone(r.Context())
+				r.Header.Set("If-None-Match", stripped)
+				cw.suffixedINM = true
+			}
+		}
+		defer cw.finish()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+type compressState int
+
+const (
+	// stateBuffering — заголовки ещё не отправлены, тело копится до MinSize.
+	stateBuffering compressState = iota
+	stateIdentity
+	stateCompressing
+)
+
+type compressWriter struct {
+	http.ResponseWriter
+	config      *Compression
+	encoding    string
+	suffixedINM bool
+
+	status      int
+	wroteHeader bool
+	state       compressState
+	buf         []byte
+	enc         encoder
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = code
+	h := cw.Header()
+
+	if code == http.StatusNotModified {
+		if cw.suffixedINM {
+			// Клиент хранит сжатое представление: подтверждаем его тег
+			if etag := h.Get("ETag"); etag != "" {
+				h.Set("ETag", suffixETag(etag, cw.encoding))
+			}
+		}
+		// 304 должен нести тот же Vary, что и ответ 200; Content-Type в нём уже снят
+		addVary(h, "Accept-Encoding")
+		cw.identity()
+		return
+	}
+	if !bodyAllowed(code) || h.Get("Content-Encoding") != "" || !cw.config.allows(h.Get("Content-Type")) {
+		cw.identity()
+		return
+	}
+	addVary(h, "Accept-Encoding")
+	if cw.encoding == "" {
+		cw.identity()
+		return
+	}
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil {
+		if n < cw.config.MinSize {
+			cw.identity()
+		} else {
+			cw.compress()
+		}
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		// Как net/http: без Content-Type тип определяется по началу тела
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+	switch cw.state {
+	case stateIdentity:
+		return cw.ResponseWriter.Write(b)
+	case stateCompressing:
+		return cw.enc.Write(b)
+	}
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) >= cw.config.MinSize {
+		cw.compress()
+	}
+	return len(b), nil
+}
+
+// Flush до начала сжатия означает потоковый ответ: он уходит без сжатия.
+func (cw *compressWriter) Flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	switch cw.state {
+	case stateBuffering:
+		cw.identity()
+	case stateCompressing:
+		_ = cw.enc.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// identity отправляет заголовки и накопленное тело без сжатия.
+func (cw *compressWriter) identity() {
+	cw.state = stateIdentity
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if len(cw.buf) > 0 {
+		_, _ = cw.ResponseWriter.Write(cw.buf)
+		cw.buf = nil
+	}
+}
+
+func (cw *compressWriter) compress() {
+	cw.state = stateCompressing
+	h := cw.Header()
+	h.Set("Content-Encoding", cw.encoding)
+	h.Del("Content-Length")
+	h.Del("Accept-Ranges")
+	if etag := h.Get("ETag"); etag != "" {
+		h.Set("ETag", suffixETag(etag, cw.encoding))
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	cw.enc = getEncoder(cw.encoding, cw.ResponseWriter)
+	if len(cw.buf) > 0 {
+		_, _ = cw.enc.Write(cw.buf)
+		cw.buf = nil
+	}
+}
+
+func (cw *compressWriter) finish() {
+	switch {
+	case !cw.wroteHeader:
+		// Обработчик ничего не записал — ответ отправит net/http
+	case cw.state == stateBuffering:
+		cw.identity()
+	case cw.state == stateCompressing:
+		_ = cw.enc.Close()
+		putEncoder(cw.encoding, cw.enc)
+		cw.enc = nil
+	}
+}
+
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+func addVary(h http.Header, field string) {
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f == "*" || strings.EqualFold(f, field) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", field)
+}
+
+// suffixETag различает представления: у сжатого ответа другое тело, и сильный тег
+// исходного ответа ему не подходит. Слабые теги остаются как есть.
+func suffixETag(etag, encoding string) string {
+	if strings.HasPrefix(etag, "W/") || len(etag) < 2 || !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	suffix := "-" + encoding + `"`
+	if strings.HasSuffix(etag, suffix) {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + suffix
+}
+
+// stripETagSuffix снимает суффикс кодировки с тегов If-None-Match; ok — был ли хотя бы один.
+func stripETagSuffix(header, encoding string) (string, bool) {
+	suffix := "-" + encoding + `"`
+	tags := strings.Split(header, ",")
+	found := false
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if strings.HasSuffix(tag, suffix) {
+			tag = strings.TrimSuffix(tag, suffix) + `"`
+			found = true
+		}
+		tags[i] = tag
+	}
+	return strings.Join(tags, ", "), found
+}
+
+// negotiateEncoding выбирает кодировку с наибольшим q из Accept-Encoding; при равных q
+// побеждает более ранняя в supported. Пустая строка — сжимать нельзя.
+func negotiateEncoding(header string, supported []string) string {
+	if header == "" {
+		return ""
+	}
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if name == "*" {
+			wildcard = q
+		} else {
+			weights[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range supported {
+		q, ok := weights[enc]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Кодировщики дорого создавать, поэтому они переиспользуются.
+var encoderPools = map[string]*sync.Pool{
+	EncodingGzip: {New: func() any {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	}},
+	EncodingBrotli: {New: func() any {
+		return brotli.NewWriterLevel(nil, 4)
+	}},
+	EncodingZstd: {New: func() any {
+		// Окно не больше 8 МиБ: больше браузеры не принимают
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithWindowSize(1<<23))
+		return w
+	}},
+}
+
+func getEncoder(encoding string, w io.Writer) encoder {
+	enc := encoderPools[encoding].Get().(encoder)
+	enc.Reset(w)
+	return enc
+}
+
+func putEncoder(encoding string, enc encoder) {
+	enc.Reset(nil)
+	encoderPools[encoding].Put(enc)
+}
@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCompression = Compression{
+	Encodings:    []string{EncodingZstd, EncodingBrotli, EncodingGzip},
+	MinSize:      64,
+	ContentTypes: []string{"application/json", "text/*"},
+	Exclude:      map[string]bool{"/notifications/longpoll": true},
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	supported := testCompression.Encodings
+	for header, want := range map[string]string{
+		"":                          "",
+		"gzip":                      EncodingGzip,
+		"gzip, deflate, br":         EncodingBrotli,
+		"gzip;q=1.0, br;q=0.5":      EncodingGzip,
+		"br;q=0, gzip;q=0":          "",
+		"identity":                  "",
+		"*":                         EncodingZstd,
+		"*;q=0.1, gzip;q=0.2":       EncodingGzip,
+		"zstd;q=0.5, gzip;q=0.5":    EncodingZstd,
+		"GZIP":                      EncodingGzip,
+		"gzip;q=oops, br":           EncodingBrotli,
+		"deflate, compress, x-gzip": "",
+	} {
+		assert.Equal(t, want, negotiateEncoding(header, supported), header)
+	}
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case EncodingGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		r = gr
+	case EncodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	case EncodingZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	default:
+		return string(body)
+	}
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestCompressionMiddleware_Encodings(t *testing.T) {
+	body := `{"messages":[` + strings.Repeat(`{"text":"hello"},`, 50) + `{}]}`
+	handler := CompressionMiddleware(testCompression, func(r *http.Request) string { return r.URL.Path },
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			// Пишем частями: порог проверяется по накопленному телу
+			_, _ = w.Write([]byte(body[:10]))
+			_, _ = w.Write([]byte(body[10:]))
+		}))
+
+	for _, encoding := range []string{EncodingGzip, EncodingBrotli, EncodingZstd} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/dialogs/1/messages", nil)
+		req.Header.Set("Accept-Encoding", encoding)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, encoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Empty(t, w.Header().Get("Content-Length"))
+		assert.Less(t, w.Body.Len(), len(body))
+		assert.Equal(t, body, decode(t, encoding, w.Body.Bytes()), encoding)
+	}
+}
+
+func TestCompressionMiddleware_Skips(t *testing.T) {
+	large := strings.Repeat("x", 200)
+	handler := CompressionMiddleware(testCompression, func(r *http.Request) string { return r.URL.Path },
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/small":
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{}`))
+			case "/image":
+				w.Header().Set("Content-Type", "image/png")
+				_, _ = w.Write([]byte(large))
+			case "/encoded":
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Encoding", "gzip")
+				_, _ = w.Write([]byte(large))
+			case "/stream":
+				w.Header().Set("Content-Type", "text/plain")
+				_, _ = w.Write([]byte("event"))
+				w.(http.Flusher).Flush()
+				_, _ = w.Write([]byte(large))
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(large))
+			}
+		}))
+
+	for path, wantEncoding := range map[string]string{
+		"/small":                  "",
+		"/image":                  "",
+		"/encoded":                "gzip",
+		"/stream":                 "",
+		"/notifications/longpoll": "",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, wantEncoding, w.Header().Get("Content-Encoding"), path)
+		assert.NotEmpty(t, w.Body.String(), path)
+		if path != "/encoded" {
+			assert.NotContains(t, w.Body.String(), "\x1f\x8b", path)
+		}
+	}
+
+	// Короткий ответ не сжат, но зависит от Accept-Encoding
+	req := httptest.NewRequest(http.MethodGet, "/small", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Equal(t, `{}`, w.Body.String())
+}
+
+func TestCompressionMiddleware_ETag(t *testing.T) {
+	body := `{"dialogs":[` + strings.Repeat(`{"peer_login":"peer"},`, 20) + `{}]}`
+	route := func(r *http.Request) string { return r.URL.Path }
+	inner := ConditionalGETMiddleware(CachePolicies{Default: "private, no-cache"}, route,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Add("Vary", "Accept-Language")
+			_, _ = w.Write([]byte(body))
+		}))
+	handler := CompressionMiddleware(testCompression, route, inner)
+
+	get := func(acceptEncoding, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/dialogs", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	identity := get("", "")
+	require.Equal(t, http.StatusOK, identity.Code)
+	plainTag := identity.Header().Get("ETag")
+	require.NotEmpty(t, plainTag)
+	assert.Equal(t, []string{"Accept-Language", "Accept-Encoding"}, identity.Header().Values("Vary"))
+
+	gzipped := get("gzip", "")
+	require.Equal(t, http.StatusOK, gzipped.Code)
+	gzipTag := gzipped.Header().Get("ETag")
+	assert.Equal(t, strings.TrimSuffix(plainTag, `"`)+`-gzip"`, gzipTag)
+	assert.Equal(t, body, decode(t, EncodingGzip, gzipped.Body.Bytes()))
+
+	// Сжатое представление подтверждается своим тегом
+	w := get("gzip", gzipTag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, gzipTag, w.Header().Get("ETag"))
+	assert.Equal(t, []string{"Accept-Language", "Accept-Encoding"}, w.Header().Values("Vary"))
+	assert.Empty(t, w.Body.String())
+
+	w = get("", plainTag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, plainTag, w.Header().Get("ETag"))
+	assert.Equal(t, []string{"Accept-Language", "Accept-Encoding"}, w.Header().Values("Vary"))
+
+	// Тег сжатого представления не подходит клиенту без сжатия
+	w = get("", gzipTag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+}
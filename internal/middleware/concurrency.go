@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"messenger_frontend/internal/apierror"
+	"messenger_frontend/internal/limiter"
+)
+
+// RoutePriorities сопоставляет шаблон маршрута с классом приоритета; остальные маршруты получают Default.
+// Ключ "POST /v1/dialogs/{id}/messages" задаёт приоритет одного метода, "/v1/dialogs/{id}/messages" — всех.
+type RoutePriorities struct {
+	Default limiter.Priority
+	Routes  map[string]limiter.Priority
+}
+
+func (p RoutePriorities) For(method, route string) limiter.Priority {
+	if prio, ok := p.Routes[method+" "+route]; ok {
+		return prio
+	}
+	if prio, ok := p.Routes[route]; ok {
+		return prio
+	}
+	return p.Default
+}
+
+// ConcurrencyLimitMiddleware отбрасывает запросы с 503, когда адаптивный лимит исчерпан.
+// Длительность запросов низкого приоритета (long-poll) на лимит не влияет.
+// route возвращает шаблон маршрута, как в MetricsMiddleware.
+func ConcurrencyLimitMiddleware(l *limiter.Limiter, priorities RoutePriorities, route func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		priority := priorities.For(r.Method, route(r))
+		token, ok := l.Acquire(priority)
+		if !ok {
+			slog.WarnContext(r.Context(), "load shedding", "priority", priority.String(), "limit", l.Limit())
+			w.Header().Set("Retry-After", "1")
+			apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, apierror.CodeOverloaded))
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		outcome := limiter.OutcomeIgnore
+		defer func() { token.Release(outcome) }()
+
+		next.ServeHTTP(rec, r)
+
+		switch {
+		case priority == limiter.PriorityLow:
+		case errors.Is(r.Context().Err(), context.Canceled):
+		case rec.status == http.StatusServiceUnavailable || rec.status == http.StatusGatewayTimeout:
+			outcome = limiter.OutcomeDropped
+		default:
+			outcome = limiter.OutcomeSuccess
+		}
+	})
+}
+
+// statusRecorder запоминает код ответа и размер тела, сохраняя поддержку Flush для проксируемых потоков.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	bytes       int64
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += int64(n)
+	return n, err
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"messenger_frontend/internal/limiter"
+)
+
+func TestConcurrencyLimitMiddleware_Sheds(t *testing.T) {
+	cfg := limiter.DefaultConfig()
+	cfg.InitialLimit, cfg.MinLimit = 1, 1
+	cfg.MinShares = nil
+	l := limiter.New(cfg)
+
+	// Слот занят другим запросом.
+	held, ok := l.Acquire(limiter.PriorityHigh)
+	assert.True(t, ok)
+	defer held.Release(limiter.OutcomeIgnore)
+
+	var called bool
+	route := func(r *http.Request) string { return r.URL.Path }
+	handler := ConcurrencyLimitMiddleware(l, RoutePriorities{Default: limiter.PriorityHigh}, route,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/dialog/send", nil))
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+}
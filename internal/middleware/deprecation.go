@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Deprecation описывает вывод маршрута из эксплуатации: с какого момента он устарел
+// и когда будет удалён. Нулевой Sunset не попадает в заголовки.
+type Deprecation struct {
+	Since  time.Time
+	Sunset time.Time
+}
+
+// DeprecatedMiddleware добавляет к ответам устаревшего маршрута заголовки Deprecation (RFC 9745)
+// и Sunset (RFC 8594), а если задан successor — ссылку на замену в Link.
+func DeprecatedMiddleware(d Deprecation, successor string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+		if !d.Sunset.IsZero() {
+			h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if successor != "" {
+			h.Add("Link", "<"+successor+`>; rel="successor-version"`)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxETagBody — ответы длиннее отдаются без ETag, чтобы не держать их в памяти целиком.
+const maxETagBody = 1 << 20
+
+// CachePolicies задаёт Cache-Control успешных ответов на GET: общий и для отдельных маршрутов.
+type CachePolicies struct {
+	Default string
+	Routes  map[string]string
+}
+
+func (c CachePolicies) For(route string) string {
+	if v, ok := c.Routes[route]; ok {
+		return v
+	}
+	return c.Default
+}
+
+// ConditionalGETMiddleware ставит на успешные ответы GET и HEAD заголовок Cache-Control
+// из policies и сильный ETag — хэш тела. Если ETag совпал с If-None-Match, клиент получает
+// 304 без тела. Ответы с no-store, со своим ETag и потоковые (вызвавшие Flush) отдаются как есть.
+func ConditionalGETMiddleware(policies CachePolicies, route func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rec := &etagRecorder{ResponseWriter: w, policy: policies.For(route(r))}
+		next.ServeHTTP(rec, r)
+		rec.finish(r)
+	})
+}
+
+// etagRecorder копит тело успешного ответа, чтобы посчитать ETag до отправки заголовков.
+type etagRecorder struct {
+	http.ResponseWriter
+	policy      string
+	status      int
+	wroteHeader bool
+	passthrough bool
+	body        bytes.Buffer
+}
+
+func (e *etagRecorder) WriteHeader(code int) {
+	if e.wroteHeader {
+		return
+	}
+	e.wroteHeader = true
+	e.status = code
+	if code != http.StatusOK {
+		e.passthrough = true
+		e.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	h := e.Header()
+	if h.Get("Cache-Control") == "" && e.policy != "" {
+		h.Set("Cache-Control", e.policy)
+	}
+	if hasDirective(h.Get("Cache-Control"), "no-store") || h.Get("ETag") != "" {
+		e.passthrough = true
+		e.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (e *etagRecorder) Write(b []byte) (int, error) {
+	if !e.wroteHeader {
+		e.WriteHeader(http.StatusOK)
+	}
+	if !e.passthrough && e.body.Len()+len(b) > maxETagBody {
+		e.release()
+	}
+	if e.passthrough {
+		return e.ResponseWriter.Write(b)
+	}
+	return e.body.Write(b)
+}
+
+// Flush означает потоковый ответ: накопленное уходит клиенту, дальше запись идёт напрямую.
+func (e *etagRecorder) Flush() {
+	if !e.wroteHeader {
+		e.WriteHeader(http.StatusOK)
+	}
+	if !e.passthrough {
+		e.release()
+	}
+	if f, ok := e.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (e *etagRecorder) Unwrap() http.ResponseWriter {
+	return e.ResponseWriter
+}
+
+// release отказывается от ETag и отправляет накопленное тело.
+func (e *etagRecorder) release() {
+	e.passthrough = true
+	e.ResponseWriter.WriteHeader(e.status)
+	_, _ = e.ResponseWriter.Write(e.body.Bytes())
+	e.body.Reset()
+}
+
+func (e *etagRecorder) finish(r *http.Request) {
+	if !e.wroteHeader {
+		e.WriteHeader(http.StatusOK)
+	}
+	if e.passthrough {
+		return
+	}
+
+	sum := sha256.Sum256(e.body.Bytes())
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	h := e.Header()
+	h.Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		h.Del("Content-Length")
+		h.Del("Content-Type")
+		e.ResponseWriter.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Length", strconv.Itoa(e.body.Len()))
+	e.ResponseWriter.WriteHeader(http.StatusOK)
+	_, _ = e.ResponseWriter.Write(e.body.Bytes())
+}
+
+// etagMatches сравнивает ETag со списком из If-None-Match. По RFC 9110 сравнение слабое:
+// префикс W/ не учитывается.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDirective(cacheControl, directive string) bool {
+	for _, d := range strings.Split(cacheControl, ",") {
+		if strings.EqualFold(strings.TrimSpace(d), directive) {
+			return true
+		}
+	}
+	return false
+}
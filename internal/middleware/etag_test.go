@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalGETMiddleware_NotModified(t *testing.T) {
+	policies := CachePolicies{
+		Default: "private, no-cache",
+		Routes:  map[string]string{"/v1/users/1": "private, max-age=60"},
+	}
+	route := func(r *http.Request) string { return r.URL.Path }
+	body := `{"dialogs":[]}`
+	handler := ConditionalGETMiddleware(policies, route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/dialogs", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.NotContains(t, etag, "W/")
+	assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+	assert.Equal(t, body, w.Body.String())
+
+	// Совпадение даёт 304 без тела, в том числе для слабой формы и списка тегов
+	for _, inm := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/dialogs", nil)
+		req.Header.Set("If-None-Match", inm)
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotModified, w.Code, inm)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+	}
+
+	// Тело изменилось — старый ETag не подходит
+	body = `{"dialogs":[{"dialog_id":"1"}]}`
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/1", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.Equal(t, "private, max-age=60", w.Header().Get("Cache-Control"))
+}
+
+func TestConditionalGETMiddleware_Passthrough(t *testing.T) {
+	policies := CachePolicies{
+		Default: "private, no-cache",
+		Routes:  map[string]string{"/notifications/longpoll": "no-store"},
+	}
+	route := func(r *http.Request) string { return r.URL.Path }
+	handler := ConditionalGETMiddleware(policies, route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.Error(w, "not found", http.StatusNotFound)
+		case "/stream":
+			_, _ = w.Write([]byte("a"))
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte("b"))
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+		cacheControl string
+	}{
+		{http.MethodGet, "/notifications/longpoll", http.StatusOK, "no-store"},
+		{http.MethodGet, "/missing", http.StatusNotFound, ""},
+		{http.MethodGet, "/stream", http.StatusOK, "private, no-cache"},
+		{http.MethodPost, "/v1/dialogs", http.StatusOK, ""},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		assert.Equal(t, tc.status, w.Code, tc.path)
+		assert.Empty(t, w.Header().Get("ETag"), tc.path)
+		assert.Equal(t, tc.cacheControl, w.Header().Get("Cache-Control"), tc.path)
+	}
+}
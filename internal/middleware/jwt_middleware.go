@@ -2,18 +2,24 @@ package middleware
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 
+	"messenger_frontend/internal/apierror"
+	"messenger_frontend/internal/audit"
 	"messenger_frontend/internal/jwt"
+	"messenger_frontend/internal/logging"
 )
 
 type contextKey string
 
 const UserIDKey = contextKey("userID")
 
-func JWTAuthMiddleware(next http.Handler) http.Handler {
+// JWTAuthMiddleware пропускает только запросы с действительным JWT. Отклонённые токены
+// попадают в журнал аудита через auditLog: в фоне и не чаще раза за окно с одного адреса,
+// чтобы мусорные токены не нагружали хранилище журнала.
+func JWTAuthMiddleware(auditLog *audit.Throttle, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		if r.Method == http.MethodPost && (r.URL.Path == "/users/login" || r.URL.Path == "/users/register") {
@@ -23,7 +29,7 @@ func JWTAuthMiddleware(next http.Handler) http.Handler {
 
 		auth := r.Header.Get("Authorization")
 		if !strings.HasPrefix(auth, "Bearer ") {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.Unauthorized(apierror.CodeTokenMissing))
 			return
 		}
 
@@ -31,12 +37,15 @@ func JWTAuthMiddleware(next http.Handler) http.Handler {
 
 		userID, err := jwt.ValidateToken(tokenStr)
 		if err != nil {
-			log.Printf("JWT validation failed: %v", err)
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			slog.InfoContext(r.Context(), "JWT validation failed", "error", err)
+			auditLog.RecordRequest(r, audit.Event{Type: audit.TokenInvalid, Reason: err.Error()})
+			apierror.Write(w, r, apierror.Unauthorized(apierror.CodeTokenInvalid))
 			return
 		}
 
+		setAccessUser(r.Context(), userID)
 		ctx := context.WithValue(r.Context(), UserIDKey, userID)
+		ctx = logging.With(ctx, "user_id", userID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
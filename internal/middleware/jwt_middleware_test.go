@@ -3,9 +3,12 @@ package middleware
 import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"messenger_frontend/internal/audit"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -44,7 +47,7 @@ func TestJWTAuthMiddleware_ValidToken(t *testing.T) {
 		w.WriteHeader(http.StatusOK)
 	})
 
-	JWTAuthMiddleware(handler).ServeHTTP(rr, req)
+	JWTAuthMiddleware(nil, handler).ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
 	assert.Equal(t, "42", userID)
@@ -60,7 +63,7 @@ func TestJWTAuthMiddleware_SkipOnLogin(t *testing.T) {
 		w.WriteHeader(http.StatusOK)
 	})
 
-	JWTAuthMiddleware(handler).ServeHTTP(rr, req)
+	JWTAuthMiddleware(nil, handler).ServeHTTP(rr, req)
 
 	assert.True(t, called)
 	assert.Equal(t, http.StatusOK, rr.Code)
@@ -74,7 +77,7 @@ func TestJWTAuthMiddleware_NoAuthHeader(t *testing.T) {
 		t.Error("handler should not be called")
 	})
 
-	JWTAuthMiddleware(handler).ServeHTTP(rr, req)
+	JWTAuthMiddleware(nil, handler).ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
 }
@@ -88,7 +91,24 @@ func TestJWTAuthMiddleware_InvalidToken(t *testing.T) {
 		t.Error("handler should not be called")
 	})
 
-	JWTAuthMiddleware(handler).ServeHTTP(rr, req)
+	sink, err := audit.NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+	require.NoError(t, err)
+	defer sink.Close()
 
+	throttle := audit.NewThrottle(audit.New(sink), time.Minute, 16)
+	authenticated := JWTAuthMiddleware(throttle, handler)
+	authenticated.ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// Повторы с того же адреса в пределах окна только считаются
+	for range 3 {
+		rr = httptest.NewRecorder()
+		authenticated.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	}
+	throttle.Close()
+
+	events, err := sink.Query(req.Context(), audit.Filter{Type: audit.TokenInvalid})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
 }
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"messenger_frontend/internal/metrics"
+)
+
+// UnmatchedRoute — метка для запросов к незарегистрированным путям; сырой путь в метках
+// Prometheus раздул бы число рядов.
+const UnmatchedRoute = "unmatched"
+
+// MetricsMiddleware считает запросы, их длительность и число одновременных запросов.
+// route возвращает шаблон маршрута, под который попал запрос.
+func MetricsMiddleware(route func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pattern := route(r)
+		if pattern == "" {
+			pattern = UnmatchedRoute
+		}
+		inflight := metrics.HTTPInFlight.WithLabelValues(pattern)
+		inflight.Inc()
+		defer inflight.Dec()
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		labels := []string{pattern, methodLabel(r.Method), strconv.Itoa(rec.status)}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	})
+}
+
+// methodLabel сводит нестандартные методы к OTHER по той же причине.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
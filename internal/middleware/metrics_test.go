@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"messenger_frontend/internal/metrics"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dialog/user", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.HTTPInFlight.WithLabelValues("/dialog/user")))
+		w.WriteHeader(http.StatusTeapot)
+	})
+	route := func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		return pattern
+	}
+	handler := MetricsMiddleware(route, mux)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/dialog/user?id=1", nil))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("/dialog/user", "GET", "418")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.HTTPInFlight.WithLabelValues("/dialog/user")))
+
+	// Неизвестные пути и методы не порождают новых рядов
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PROPFIND", "/random/42", nil))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(UnmatchedRoute, "OTHER", "404")))
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+
+	"messenger_frontend/internal/apierror"
+)
+
+// MuxErrorsMiddleware отдаёт ответы 404 и 405, которые ServeMux формирует сам, в формате
+// ошибок API вместо текста "404 page not found". Заголовок Allow ответа 405 сохраняется.
+// Ответы зарегистрированных обработчиков и редиректы mux не меняются.
+func MuxErrorsMiddleware(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Пустой шаблон означает, что запрос обслуживает внутренний обработчик mux
+		if _, pattern := mux.Handler(r); pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		mux.ServeHTTP(&muxErrorWriter{ResponseWriter: w, r: r}, r)
+	})
+}
+
+// muxErrorWriter подменяет текстовое тело ошибки mux конвертом apierror.
+type muxErrorWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	replaced bool
+}
+
+func (m *muxErrorWriter) WriteHeader(code int) {
+	switch code {
+	case http.StatusNotFound:
+		m.replaced = true
+		apierror.Write(m.ResponseWriter, m.r, apierror.NotFound(apierror.CodeNotFound))
+	case http.StatusMethodNotAllowed:
+		m.replaced = true
+		apierror.Write(m.ResponseWriter, m.r, apierror.MethodNotAllowed())
+	default:
+		m.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (m *muxErrorWriter) Write(b []byte) (int, error) {
+	if m.replaced {
+		return len(b), nil
+	}
+	return m.ResponseWriter.Write(b)
+}
+
+func (m *muxErrorWriter) Unwrap() http.ResponseWriter {
+	return m.ResponseWriter
+}
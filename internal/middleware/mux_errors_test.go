@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxErrorsMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/dialogs", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "handler's own 404", http.StatusNotFound)
+	})
+	mux.HandleFunc("POST /v1/dialogs", func(http.ResponseWriter, *http.Request) {})
+	mux.HandleFunc("/docs/", func(http.ResponseWriter, *http.Request) {})
+	handler := MuxErrorsMiddleware(mux)
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+		code         string
+	}{
+		{http.MethodGet, "/v1/missing", http.StatusNotFound, `"code":"not_found"`},
+		{http.MethodDelete, "/v1/dialogs", http.StatusMethodNotAllowed, `"code":"method_not_allowed"`},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		assert.Equal(t, tc.status, w.Code, tc.path)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"), tc.path)
+		assert.Contains(t, w.Body.String(), tc.code, tc.path)
+		assert.NotContains(t, w.Body.String(), "page not found", tc.path)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/v1/dialogs", nil))
+	assert.Equal(t, "GET, HEAD, POST", w.Header().Get("Allow"))
+
+	// Ответы обработчиков и редиректы mux не трогаются
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/dialogs", nil))
+	assert.Equal(t, "handler's own 404\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+}
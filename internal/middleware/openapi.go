@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+
+	"messenger_frontend/internal/apierror"
+)
+
+// maxValidatedResponse — ответы длиннее не проверяются, чтобы не держать их в памяти целиком.
+const maxValidatedResponse = 1 << 20
+
+// OpenAPIValidator сверяет запросы и ответы с документом OpenAPI.
+type OpenAPIValidator interface {
+	ValidateRequest(r *http.Request) error
+	ValidateResponse(r *http.Request, status int, header http.Header, body []byte) error
+}
+
+// OpenAPIValidationMiddleware отклоняет запросы, не соответствующие документу, ответом 400
+// с нарушениями полей в details. С checkResponses ответы тоже сверяются с документом,
+// но расхождения только пишутся в лог: клиент получает ответ без изменений.
+func OpenAPIValidationMiddleware(v OpenAPIValidator, checkRequests, checkResponses bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if checkRequests {
+			if err := v.ValidateRequest(r); err != nil {
+				apierror.Write(w, r, err)
+				return
+			}
+		}
+		if !checkResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &teeRecorder{statusRecorder: statusRecorder{ResponseWriter: w, status: http.StatusOK}}
+		next.ServeHTTP(rec, r)
+		if rec.overflow {
+			return
+		}
+		if err := v.ValidateResponse(r, rec.status, w.Header(), rec.body.Bytes()); err != nil {
+			slog.WarnContext(r.Context(), "response does not match OpenAPI document",
+				"method", r.Method, "path", r.URL.Path, "status", rec.status, "error", err)
+		}
+	})
+}
+
+// teeRecorder пишет ответ клиенту и сохраняет копию тела для проверки.
+type teeRecorder struct {
+	statusRecorder
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (t *teeRecorder) Write(b []byte) (int, error) {
+	n, err := t.statusRecorder.Write(b)
+	if !t.overflow {
+		if t.body.Len()+n > maxValidatedResponse {
+			t.overflow = true
+			t.body.Reset()
+		} else {
+			t.body.Write(b[:n])
+		}
+	}
+	return n, err
+}
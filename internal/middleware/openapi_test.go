@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"messenger_frontend/internal/apierror"
+)
+
+type fakeValidator struct {
+	requestErr error
+	responseOK bool
+	gotBody    []byte
+	gotStatus  int
+}
+
+func (f *fakeValidator) ValidateRequest(*http.Request) error {
+	return f.requestErr
+}
+
+func (f *fakeValidator) ValidateResponse(_ *http.Request, status int, _ http.Header, body []byte) error {
+	f.gotStatus, f.gotBody = status, body
+	if f.responseOK {
+		return nil
+	}
+	return errors.New("missing property")
+}
+
+func TestOpenAPIValidationMiddleware_RejectsInvalidRequest(t *testing.T) {
+	reqErr := apierror.BadRequest(apierror.CodeInvalidArgument)
+	reqErr.Details = []apierror.FieldViolation{{Field: "text", Description: "minimum string length is 1"}}
+	v := &fakeValidator{requestErr: reqErr}
+	called := false
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true })
+
+	w := httptest.NewRecorder()
+	OpenAPIValidationMiddleware(v, true, false, next).
+		ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/dialogs/1/messages", nil))
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"text"`)
+}
+
+func TestOpenAPIValidationMiddleware_LogsResponseMismatch(t *testing.T) {
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(prev)
+
+	v := &fakeValidator{requestErr: errors.New("ignored when requests are not checked")}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	})
+
+	w := httptest.NewRecorder()
+	OpenAPIValidationMiddleware(v, false, true, next).
+		ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/dialogs", nil))
+
+	// Ответ доходит до клиента без изменений, расхождение только в логе
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"id":1}`, w.Body.String())
+	assert.Equal(t, http.StatusCreated, v.gotStatus)
+	assert.Equal(t, `{"id":1}`, string(v.gotBody))
+	assert.Contains(t, logs.String(), "response does not match OpenAPI document")
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"messenger_frontend/internal/logging"
+)
+
+// RequestIDHeader передаёт идентификатор запроса от клиента через шлюз в upstream-сервисы и обратно.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware берёт идентификатор из X-Request-ID или создаёт новый, возвращает его
+// в ответе и добавляет к логам вместе с методом и шаблоном маршрута, как в метриках и журнале доступа.
+func RequestIDMiddleware(route func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+			r.Header.Set(RequestIDHeader, id)
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		pattern := route(r)
+		if pattern == "" {
+			pattern = UnmatchedRoute
+		}
+		ctx := logging.WithRequestID(r.Context(), id)
+		ctx = logging.With(ctx, "method", r.Method, "route", pattern)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID пропускает только короткие печатные ASCII-строки, чтобы клиент не мог
+// подсунуть в логи и заголовки что-то лишнее.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
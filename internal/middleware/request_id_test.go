@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"messenger_frontend/internal/logging"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := RequestIDMiddleware(func(*http.Request) string { return "" }, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/dialog/user", nil)
+	req.Header.Set(RequestIDHeader, "client-id-1")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, "client-id-1", seen)
+	assert.Equal(t, "client-id-1", rr.Header().Get(RequestIDHeader))
+
+	// Некорректный идентификатор заменяется сгенерированным
+	req = httptest.NewRequest(http.MethodGet, "/dialog/user", nil)
+	req.Header.Set(RequestIDHeader, strings.Repeat("x", 200))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Len(t, seen, 32)
+	assert.Equal(t, seen, rr.Header().Get(RequestIDHeader))
+}
+
+func TestRequestIDMiddleware_LogsRoutePattern(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, slog.LevelInfo)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/dialogs/{id}/messages", func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "handled")
+	})
+	route := func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		return strings.TrimPrefix(pattern, "GET ")
+	}
+
+	RequestIDMiddleware(route, mux).ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodGet, "/v1/dialogs/123/messages", nil))
+
+	// В поле route — шаблон, а не путь с идентификатором
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "/v1/dialogs/{id}/messages", record["route"])
+}
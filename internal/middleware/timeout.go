@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Timeouts возвращает бюджет времени для шаблона маршрута.
+type Timeouts interface {
+	For(route string) time.Duration
+}
+
+// RouteTimeouts задаёт бюджет времени на обработку запроса: общий и для отдельных маршрутов.
+type RouteTimeouts struct {
+	Default time.Duration
+	Routes  map[string]time.Duration
+}
+
+func (t RouteTimeouts) For(route string) time.Duration {
+	if d, ok := t.Routes[route]; ok {
+		return d
+	}
+	return t.Default
+}
+
+// TimeoutMiddleware ограничивает контекст запроса дедлайном маршрута; route возвращает
+// шаблон маршрута, например "/v1/dialogs/{id}/messages".
+// Контекст по-прежнему отменяется, если клиент закрыл соединение.
+func TimeoutMiddleware(timeouts Timeouts, route func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := timeouts.For(route(r))
+		if d <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
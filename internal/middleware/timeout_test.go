@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutMiddleware_RouteBudget(t *testing.T) {
+	timeouts := RouteTimeouts{
+		Default: 5 * time.Second,
+		Routes: map[string]time.Duration{
+			"/notifications/longpoll":   30 * time.Second,
+			"/v1/dialogs/{id}/messages": 2 * time.Second,
+		},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/notifications/longpoll", func(http.ResponseWriter, *http.Request) {})
+	mux.HandleFunc("GET /v1/dialogs/{id}/messages", func(http.ResponseWriter, *http.Request) {})
+	route := func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		_, path, _ := strings.Cut(pattern, " ")
+		if path == "" {
+			return pattern
+		}
+		return path
+	}
+
+	var remaining time.Duration
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		assert.True(t, ok)
+		remaining = time.Until(deadline)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/notifications/longpoll", nil)
+	TimeoutMiddleware(timeouts, route, handler).ServeHTTP(httptest.NewRecorder(), req)
+	assert.Greater(t, remaining, 25*time.Second)
+
+	req = httptest.NewRequest(http.MethodGet, "/dialog/user", nil)
+	TimeoutMiddleware(timeouts, route, handler).ServeHTTP(httptest.NewRecorder(), req)
+	assert.LessOrEqual(t, remaining, 5*time.Second)
+	assert.Greater(t, remaining, 2*time.Second)
+
+	// Бюджет задаётся шаблоном, а не конкретным путём
+	req = httptest.NewRequest(http.MethodGet, "/v1/dialogs/42/messages", nil)
+	TimeoutMiddleware(timeouts, route, handler).ServeHTTP(httptest.NewRecorder(), req)
+	assert.LessOrEqual(t, remaining, 2*time.Second)
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"messenger_frontend/internal/logging"
+	"messenger_frontend/internal/tracing"
+)
+
+// TracingMiddleware открывает серверный спан на каждый запрос, продолжая трассу из traceparent
+// клиента, и добавляет trace_id к логам. route возвращает шаблон маршрута, как в MetricsMiddleware.
+func TracingMiddleware(route func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pattern := route(r)
+		if pattern == "" {
+			pattern = UnmatchedRoute
+		}
+		ctx := tracing.Extract(r.Context(), r.Header)
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+pattern,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(methodLabel(r.Method)),
+				semconv.HTTPRoute(pattern),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+		if sc := span.SpanContext(); sc.HasTraceID() {
+			ctx = logging.With(ctx, "trace_id", sc.TraceID().String())
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
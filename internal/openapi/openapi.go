@@ -0,0 +1,150 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+
+	"messenger_frontend/internal/apierror"
+	"messenger_frontend/internal/router"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+//go:embed docs.html
+var docs []byte
+
+// Spec возвращает документ OpenAPI шлюза в JSON.
+func Spec() []byte {
+	return spec
+}
+
+// RegisterHandlers публикует документ на /openapi.json и страницу документации на /docs.
+func RegisterHandlers(mux router.Mux) {
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(spec)
+	})
+	mux.HandleFunc("GET /docs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(docs)
+	})
+}
+
+// Validator сверяет запросы и ответы с документом OpenAPI.
+type Validator struct {
+	router routers.Router
+}
+
+func NewValidator() (*Validator, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: load spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("openapi: invalid spec: %w", err)
+	}
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: build router: %w", err)
+	}
+	return &Validator{router: router}, nil
+}
+
+// options отключает проверку авторизации: токен проверяет JWTAuthMiddleware.
+var options = &openapi3filter.Options{
+	MultiError:         true,
+	AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+}
+
+// ValidateRequest проверяет параметры и тело запроса. Для маршрутов и методов, которых нет
+// в документе, возвращает nil: на них ответит mux. Ошибка — *apierror.Error с полями в Details.
+func (v *Validator) ValidateRequest(r *http.Request) error {
+	input, ok := v.input(r)
+	if !ok {
+		return nil
+	}
+	err := openapi3filter.ValidateRequest(r.Context(), input)
+	if err == nil {
+		return nil
+	}
+	e := apierror.BadRequest(apierror.CodeInvalidArgument)
+	e.Details = violations(err)
+	return e
+}
+
+// ValidateResponse проверяет статус, заголовки и тело ответа на запрос r.
+func (v *Validator) ValidateResponse(r *http.Request, status int, header http.Header, body []byte) error {
+	input, ok := v.input(r)
+	if !ok {
+		return fmt.Errorf("openapi: %s %s is not documented", r.Method, r.URL.Path)
+	}
+	resp := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 status,
+		Header:                 header,
+		Options:                options,
+	}
+	resp.SetBodyBytes(body)
+	return openapi3filter.ValidateResponse(r.Context(), resp)
+}
+
+func (v *Validator) input(r *http.Request) (*openapi3filter.RequestValidationInput, bool) {
+	route, params, err := v.router.FindRoute(r)
+	if err != nil {
+		return nil, false
+	}
+	return &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: params,
+		Route:      route,
+		Options:    options,
+	}, true
+}
+
+// violations раскладывает ошибку проверки на нарушения отдельных полей и параметров.
+func violations(err error) []apierror.FieldViolation {
+	var multi openapi3.MultiError
+	if !errors.As(err, &multi) {
+		multi = openapi3.MultiError{err}
+	}
+	var out []apierror.FieldViolation
+	for _, err := range multi {
+		var reqErr *openapi3filter.RequestError
+		if !errors.As(err, &reqErr) {
+			out = append(out, apierror.FieldViolation{Description: err.Error()})
+			continue
+		}
+		field := ""
+		if reqErr.Parameter != nil {
+			field = reqErr.Parameter.Name
+		}
+		var schemaErrs openapi3.MultiError
+		if !errors.As(reqErr.Err, &schemaErrs) {
+			schemaErrs = openapi3.MultiError{reqErr.Err}
+		}
+		for _, err := range schemaErrs {
+			v := apierror.FieldViolation{Field: field, Description: reqErr.Reason}
+			var schemaErr *openapi3.SchemaError
+			if errors.As(err, &schemaErr) {
+				if ptr := schemaErr.JSONPointer(); len(ptr) > 0 {
+					v.Field = strings.Join(ptr, ".")
+				}
+				v.Description = schemaErr.Reason
+			} else if err != nil && v.Description == "" {
+				v.Description = err.Error()
+			}
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,160 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"messenger_frontend/internal/apierror"
+	"messenger_frontend/internal/handlers"
+	"messenger_frontend/internal/health"
+	"messenger_frontend/internal/middleware"
+)
+
+type document struct {
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+// recordingMux запоминает шаблоны, под которыми пакеты регистрируют маршруты.
+type recordingMux struct {
+	*http.ServeMux
+	patterns []string
+}
+
+func (m *recordingMux) Handle(pattern string, handler http.Handler) {
+	m.patterns = append(m.patterns, pattern)
+	m.ServeMux.Handle(pattern, handler)
+}
+
+func (m *recordingMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.patterns = append(m.patterns, pattern)
+	m.ServeMux.HandleFunc(pattern, handler)
+}
+
+// gatewayMux регистрирует маршруты так же, как main.
+func gatewayMux() *recordingMux {
+	mux := &recordingMux{ServeMux: http.NewServeMux()}
+	dialogs := handlers.NewDialogHandlerService(nil, nil)
+	dialogs.RegisterHandlers(mux)
+	dialogs.RegisterLegacyHandlers(mux, middleware.Deprecation{})
+	users := handlers.NewUserHandlerService(nil, nil)
+	users.RegisterHandlers(mux)
+	users.RegisterLegacyHandlers(mux, middleware.Deprecation{})
+	handlers.NewNotificationHandler("http://notifications").RegisterHandlers(mux)
+	health.NewChecker(nil, time.Second).RegisterHandlers(mux)
+	RegisterHandlers(mux)
+	return mux
+}
+
+func TestSpec_Valid(t *testing.T) {
+	_, err := NewValidator()
+	require.NoError(t, err)
+}
+
+// Каждая операция документа должна вести на зарегистрированный маршрут, а каждый маршрут —
+// быть описан в документе.
+func TestSpec_MatchesRoutes(t *testing.T) {
+	var doc document
+	require.NoError(t, json.Unmarshal(Spec(), &doc))
+	mux := gatewayMux()
+
+	documented := make(map[string]bool)
+	for path, item := range doc.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			method = strings.ToUpper(method)
+			documented[method+" "+path] = true
+
+			r := httptest.NewRequest(method, strings.ReplaceAll(path, "{id}", "1"), nil)
+			_, pattern := mux.Handler(r)
+			assert.NotEmpty(t, pattern, "%s %s is documented but not routed", method, path)
+		}
+	}
+
+	// Шаблоны без метода (notifications, healthz, readyz) принимают любой метод,
+	// поэтому для них достаточно описать путь хотя бы одной операцией
+	for _, pattern := range mux.patterns {
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			method, path = "", pattern
+		}
+		if path == "/docs" {
+			// Страница документации — HTML для людей, а не операция API
+			continue
+		}
+		if method != "" {
+			assert.True(t, documented[method+" "+path], "%s is not documented", pattern)
+			continue
+		}
+		_, found := doc.Paths[path]
+		assert.True(t, found, "%s is not documented", pattern)
+	}
+}
+
+func TestValidator_ValidateRequest(t *testing.T) {
+	v, err := NewValidator()
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/dialogs/10/messages", bytes.NewBufferString(`{"text":""}`))
+	r.Header.Set("Content-Type", "application/json")
+	err = v.ValidateRequest(r)
+	var apiErr *apierror.Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+	require.Len(t, apiErr.Details, 1)
+	assert.Equal(t, "text", apiErr.Details[0].Field)
+
+	// Тело остаётся доступным обработчику
+	r = httptest.NewRequest(http.MethodPost, "/v1/dialogs/10/messages", bytes.NewBufferString(`{"text":"hi"}`))
+	r.Header.Set("Content-Type", "application/json")
+	require.NoError(t, v.ValidateRequest(r))
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	assert.Equal(t, "hi", body["text"])
+
+	r = httptest.NewRequest(http.MethodGet, "/v1/dialogs/abc/messages", nil)
+	err = v.ValidateRequest(r)
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "id", apiErr.Details[0].Field)
+
+	// Неописанные маршруты пропускаются: на них ответит mux
+	assert.NoError(t, v.ValidateRequest(httptest.NewRequest(http.MethodGet, "/unknown", nil)))
+}
+
+func TestValidator_ValidateResponse(t *testing.T) {
+	v, err := NewValidator()
+	require.NoError(t, err)
+	r := httptest.NewRequest(http.MethodGet, "/v1/users/1", nil)
+	header := http.Header{"Content-Type": {"application/json"}}
+
+	assert.NoError(t, v.ValidateResponse(r, http.StatusOK, header, []byte(`{"id":"1","login":"user1"}`)))
+	assert.Error(t, v.ValidateResponse(r, http.StatusOK, header, []byte(`{"id":1,"login":"user1"}`)))
+	assert.NoError(t, v.ValidateResponse(r, http.StatusNotFound, header,
+		[]byte(`{"code":"user_not_found","message":"Пользователь не найден"}`)))
+}
+
+func TestRegisterHandlers(t *testing.T) {
+	mux := http.NewServeMux()
+	RegisterHandlers(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(Spec()), w.Body.String())
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "openapi.json")
+}
@@ -0,0 +1,28 @@
+package openapitest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"messenger_frontend/internal/openapi"
+)
+
+var (
+	once      sync.Once
+	validator *openapi.Validator
+	loadErr   error
+)
+
+// Check сверяет ответ rec на запрос r с документом и проваливает тест при расхождении.
+func Check(t testing.TB, r *http.Request, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	once.Do(func() { validator, loadErr = openapi.NewValidator() })
+	if loadErr != nil {
+		t.Fatalf("openapi: %v", loadErr)
+	}
+	if err := validator.ValidateResponse(r, rec.Code, rec.Header(), rec.Body.Bytes()); err != nil {
+		t.Errorf("%s %s: response does not match the OpenAPI document: %v", r.Method, r.URL.Path, err)
+	}
+}
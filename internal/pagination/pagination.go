@@ -0,0 +1,188 @@
+package pagination
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"messenger_frontend/internal/apierror"
+	"messenger_frontend/internal/dto"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+)
+
+// keyContext отделяет ключ курсоров от секрета, из которого он получен: один и тот же
+// jwt.secret не должен давать одинаковые подписи токенов и курсоров.
+const keyContext = "messenger_frontend pagination cursor v1"
+
+var errInvalidCursor = errors.New("cursor is malformed or signed with another key")
+
+// Page — страница списка. Dialog-сервис пока умеет только limit/offset, поэтому курсор
+// хранит смещение; для клиента он непрозрачен, и переход на keyset-пагинацию его не заметит.
+type Page struct {
+	// Scope привязывает курсор к списку: курсор диалогов одного пользователя не подходит
+	// ни к другому пользователю, ни к сообщениям.
+	Scope  string `json:"s"`
+	Offset int32  `json:"o"`
+	Limit  int32  `json:"l"`
+}
+
+// Paginator разбирает параметры страницы и подписывает курсоры.
+type Paginator struct {
+	key          []byte
+	defaultLimit int32
+	maxLimit     int32
+}
+
+// New создаёт Paginator. Пустой secret заменяется случайным ключом: курсоры тогда
+// действительны только до перезапуска процесса.
+func New(secret []byte, defaultLimit, maxLimit int) *Paginator {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(keyContext))
+	return &Paginator{
+		key:          mac.Sum(nil),
+		defaultLimit: int32(defaultLimit),
+		maxLimit:     int32(maxLimit),
+	}
+}
+
+// Parse читает limit, offset и cursor из запроса. Курсор задаёт смещение и размер страницы,
+// limit рядом с ним меняет только размер. offset оставлен для старых клиентов и вместе
+// с cursor не принимается. Ошибка — *apierror.Error с нарушениями в Details.
+func (p *Paginator) Parse(q url.Values, scope string) (Page, error) {
+	page := Page{Scope: scope, Limit: p.defaultLimit}
+	var violations []apierror.FieldViolation
+	violate := func(field, format string, args ...interface{}) {
+		violations = append(violations, apierror.FieldViolation{Field: field, Description: fmt.Sprintf(format, args...)})
+	}
+
+	if token := q.Get("cursor"); token != "" {
+		cur, err := p.decode(token)
+		switch {
+		case err != nil:
+			violate("cursor", "%v", err)
+		case cur.Scope != scope:
+			violate("cursor", "cursor belongs to another list")
+		default:
+			page = cur
+		}
+		if q.Has("offset") {
+			violate("offset", "offset cannot be combined with cursor")
+		}
+	} else if s := q.Get("offset"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil || n < 0 {
+			violate("offset", "must be an integer between 0 and %d", math.MaxInt32)
+		} else {
+			page.Offset = int32(n)
+		}
+	}
+
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil || n < 1 || n > int64(p.maxLimit) {
+			violate("limit", "must be an integer between 1 and %d", p.maxLimit)
+		} else {
+			page.Limit = int32(n)
+		}
+	}
+
+	if len(violations) > 0 {
+		e := apierror.BadRequest(apierror.CodePagingInvalid)
+		e.Details = violations
+		return Page{}, e
+	}
+	return page, nil
+}
+
+// Encode возвращает курсор страницы: base64url от JSON и подписи HMAC-SHA256 через точку.
+func (p *Paginator) Encode(page Page) string {
+	payload, _ := json.Marshal(page)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(p.sign(payload))
+}
+
+func (p *Paginator) decode(token string) (Page, error) {
+	encPayload, encSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return Page{}, errInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return Page{}, errInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encSig)
+	if err != nil || !hmac.Equal(sig, p.sign(payload)) {
+		return Page{}, errInvalidCursor
+	}
+	var page Page
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&page); err != nil {
+		return Page{}, errInvalidCursor
+	}
+	// Подписанный курсор выпускает сам шлюз, но maxLimit мог уменьшиться после его выдачи
+	if page.Offset < 0 || page.Limit < 1 || page.Limit > p.maxLimit {
+		return Page{}, errInvalidCursor
+	}
+	return page, nil
+}
+
+func (p *Paginator) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Links строит ссылки на соседние страницы относительно пути u, сохраняя остальные
+// параметры query. hasNext — есть ли элементы после текущей страницы.
+func (p *Paginator) Links(u *url.URL, page Page, hasNext bool) dto.Links {
+	link := func(target Page) string {
+		q := u.Query()
+		q.Del("offset")
+		q.Del("limit")
+		q.Set("cursor", p.Encode(target))
+		return u.Path + "?" + q.Encode()
+	}
+
+	var links dto.Links
+	if hasNext && page.Offset <= math.MaxInt32-page.Limit {
+		next := page
+		next.Offset += page.Limit
+		links.Next = link(next)
+	}
+	if page.Offset > 0 {
+		prev := page
+		prev.Offset = max(0, page.Offset-page.Limit)
+		links.Prev = link(prev)
+	}
+	return links
+}
+
+// LinkHeader форматирует ссылки для заголовка Link (RFC 8288); без ссылок — пустая строка.
+func LinkHeader(links dto.Links) string {
+	var parts []string
+	if links.Next != "" {
+		parts = append(parts, "<"+links.Next+`>; rel="next"`)
+	}
+	if links.Prev != "" {
+		parts = append(parts, "<"+links.Prev+`>; rel="prev"`)
+	}
+	return strings.Join(parts, ", ")
+}
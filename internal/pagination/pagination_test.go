@@ -0,0 +1,98 @@
+package pagination
+
+import (
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"messenger_frontend/internal/apierror"
+	"messenger_frontend/internal/dto"
+)
+
+func TestParse_Defaults(t *testing.T) {
+	p := New([]byte("secret"), 20, 50)
+
+	page, err := p.Parse(url.Values{}, "dialogs:1")
+	require.NoError(t, err)
+	assert.Equal(t, Page{Scope: "dialogs:1", Limit: 20}, page)
+
+	page, err = p.Parse(url.Values{"limit": {"50"}, "offset": {"10"}}, "dialogs:1")
+	require.NoError(t, err)
+	assert.Equal(t, Page{Scope: "dialogs:1", Limit: 50, Offset: 10}, page)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	p := New([]byte("secret"), 20, 50)
+	cursor := p.Encode(Page{Scope: "dialogs:1", Limit: 20, Offset: 20})
+
+	for name, tc := range map[string]struct {
+		query url.Values
+		field string
+	}{
+		"limit not a number":   {url.Values{"limit": {"ten"}}, "limit"},
+		"limit zero":           {url.Values{"limit": {"0"}}, "limit"},
+		"limit above maximum":  {url.Values{"limit": {"51"}}, "limit"},
+		"negative offset":      {url.Values{"offset": {"-1"}}, "offset"},
+		"offset overflow":      {url.Values{"offset": {"3000000000"}}, "offset"},
+		"garbage cursor":       {url.Values{"cursor": {"abc"}}, "cursor"},
+		"tampered cursor":      {url.Values{"cursor": {"x" + cursor}}, "cursor"},
+		"cursor of other list": {url.Values{"cursor": {p.Encode(Page{Scope: "dialogs:2", Limit: 20})}}, "cursor"},
+		"cursor with offset":   {url.Values{"cursor": {cursor}, "offset": {"0"}}, "offset"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := p.Parse(tc.query, "dialogs:1")
+			var apiErr *apierror.Error
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, apierror.CodePagingInvalid, apiErr.Code)
+			require.Len(t, apiErr.Details, 1)
+			assert.Equal(t, tc.field, apiErr.Details[0].Field)
+		})
+	}
+}
+
+func TestCursor_SignedWithAnotherKey(t *testing.T) {
+	cursor := New([]byte("old"), 20, 50).Encode(Page{Scope: "dialogs:1", Limit: 20, Offset: 20})
+
+	_, err := New([]byte("new"), 20, 50).Parse(url.Values{"cursor": {cursor}}, "dialogs:1")
+	assert.Error(t, err)
+
+	page, err := New([]byte("old"), 20, 50).Parse(url.Values{"cursor": {cursor}}, "dialogs:1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(20), page.Offset)
+}
+
+func TestLinks(t *testing.T) {
+	p := New([]byte("secret"), 20, 50)
+	r := httptest.NewRequest("GET", "/dialog/messages?dialog_id=10&limit=2&offset=3", nil)
+	page := Page{Scope: "messages:1:10", Limit: 2, Offset: 3}
+
+	links := p.Links(r.URL, page, true)
+	require.NotEmpty(t, links.Next)
+	require.NotEmpty(t, links.Prev)
+	for link, offset := range map[string]int32{links.Next: 5, links.Prev: 1} {
+		u, err := url.Parse(link)
+		require.NoError(t, err)
+		assert.Equal(t, "/dialog/messages", u.Path)
+		assert.Equal(t, "10", u.Query().Get("dialog_id"))
+		assert.False(t, u.Query().Has("offset"))
+
+		got, err := p.Parse(u.Query(), "messages:1:10")
+		require.NoError(t, err)
+		assert.Equal(t, Page{Scope: "messages:1:10", Limit: 2, Offset: offset}, got)
+	}
+
+	// Первая и последняя страница
+	assert.Equal(t, dto.Links{}, p.Links(r.URL, Page{Scope: "messages:1:10", Limit: 2}, false))
+}
+
+func TestLinkHeader(t *testing.T) {
+	assert.Empty(t, LinkHeader(dto.Links{}))
+	header := LinkHeader(dto.Links{Next: "/v1/dialogs?cursor=b", Prev: "/v1/dialogs?cursor=a"})
+	assert.Equal(t, `</v1/dialogs?cursor=b>; rel="next", </v1/dialogs?cursor=a>; rel="prev"`, header)
+	assert.False(t, strings.Contains(LinkHeader(dto.Links{Next: "/n"}), "prev"))
+}
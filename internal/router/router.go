@@ -0,0 +1,11 @@
+// Package router описывает, куда пакеты шлюза регистрируют свои маршруты.
+package router
+
+import "net/http"
+
+// Mux — часть *http.ServeMux, которой пользуются RegisterHandlers. Через него тесты
+// записывают зарегистрированные шаблоны и сверяют их с документом OpenAPI.
+type Mux interface {
+	Handle(pattern string, handler http.Handler)
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
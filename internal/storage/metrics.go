@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+
+	"messenger_frontend/internal/logging"
+	"messenger_frontend/internal/metrics"
+)
+
+// metricsHook замеряет длительность каждой команды Redis, в том числе внутри pipeline,
+// и добавляет её ко времени запроса в Redis для журнала доступа.
+type metricsHook struct{}
+
+func (metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		elapsed := time.Since(start)
+		observe(cmd, elapsed)
+		logging.ObserveUpstream(ctx, "redis", elapsed)
+		return err
+	}
+}
+
+func (metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		elapsed := time.Since(start)
+		for _, cmd := range cmds {
+			observe(cmd, elapsed)
+		}
+		logging.ObserveUpstream(ctx, "redis", elapsed)
+		return err
+	}
+}
+
+func observe(cmd redis.Cmder, elapsed time.Duration) {
+	metrics.RedisCommandDuration.WithLabelValues(cmd.Name(), result(cmd.Err())).Observe(elapsed.Seconds())
+}
+
+func result(err error) string {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, redis.Nil):
+		return "miss"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "error"
+	}
+}
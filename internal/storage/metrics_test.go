@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResult(t *testing.T) {
+	assert.Equal(t, "ok", result(nil))
+	assert.Equal(t, "miss", result(redis.Nil))
+	assert.Equal(t, "timeout", result(context.DeadlineExceeded))
+	assert.Equal(t, "timeout", result(os.ErrDeadlineExceeded))
+	assert.Equal(t, "error", result(errors.New("WRONGTYPE")))
+}
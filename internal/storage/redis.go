@@ -1,18 +1,79 @@
 package storage
 
 import (
+	"context"
+	"fmt"
+
 	redis "github.com/redis/go-redis/v9"
-	"os"
-)
 
-var (
-	Rdb *redis.Client
+	"messenger_frontend/internal/config"
+	"messenger_frontend/internal/tlsutil"
 )
 
-func InitRedis() {
-	Rdb = redis.NewClient(&redis.Options{
-		Addr:     os.Getenv("REDIS_ADDR"),
-		Password: "",
-		DB:       0,
-	})
+// NewRedis создаёт клиент для выбранной топологии и проверяет, что Redis отвечает.
+// Сертификаты TLS перечитываются с диска до отмены ctx.
+func NewRedis(ctx context.Context, cfg config.RedisConfig) (redis.UniversalClient, error) {
+	opts, err := universalOptions(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	rdb := newClient(cfg.Mode, opts)
+	rdb.AddHook(metricsHook{})
+	rdb.AddHook(tracingHook{})
+
+	pingCtx, cancel := context.WithTimeout(ctx, cfg.DialTimeout+cfg.ReadTimeout)
+	defer cancel()
+	if err := rdb.Ping(pingCtx).Err(); err != nil {
+		_ = rdb.Close()
+		return nil, fmt.Errorf("redis %s: %w", cfg.Mode, err)
+	}
+	return rdb, nil
+}
+
+func universalOptions(ctx context.Context, cfg config.RedisConfig) (*redis.UniversalOptions, error) {
+	opts := &redis.UniversalOptions{
+		Addrs:            cfg.Addrs,
+		MasterName:       cfg.MasterName,
+		Username:         cfg.Username,
+		Password:         cfg.Password.Value(),
+		SentinelUsername: cfg.SentinelUsername,
+		SentinelPassword: cfg.SentinelPassword.Value(),
+		DB:               cfg.DB,
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     cfg.MinIdleConns,
+		DialTimeout:      cfg.DialTimeout,
+		ReadTimeout:      cfg.ReadTimeout,
+		WriteTimeout:     cfg.WriteTimeout,
+		PoolTimeout:      cfg.PoolTimeout,
+	}
+	if cfg.Mode == config.RedisStandalone {
+		opts.Addrs = []string{cfg.Addr}
+	}
+	if cfg.TLS.Enabled {
+		tlsConfig, err := tlsutil.NewClientConfig(ctx, tlsutil.ClientOptions{
+			CAFile:         cfg.TLS.CAFile,
+			CertFile:       cfg.TLS.CertFile,
+			KeyFile:        cfg.TLS.KeyFile,
+			ServerName:     cfg.TLS.ServerName,
+			ReloadInterval: cfg.TLS.ReloadInterval,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("redis tls: %w", err)
+		}
+		opts.TLSConfig = tlsConfig
+	}
+	return opts, nil
+}
+
+// newClient выбирает клиента по явно заданному режиму, а не по набору полей,
+// как это делает redis.NewUniversalClient.
+func newClient(mode string, opts *redis.UniversalOptions) redis.UniversalClient {
+	switch mode {
+	case config.RedisSentinel:
+		return redis.NewFailoverClient(opts.Failover())
+	case config.RedisCluster:
+		return redis.NewClusterClient(opts.Cluster())
+	default:
+		return redis.NewClient(opts.Simple())
+	}
 }
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"messenger_frontend/internal/config"
+)
+
+// fakeRedis отвечает на команды рукопожатия и PING и запоминает полученные команды.
+type fakeRedis struct {
+	mu       sync.Mutex
+	commands [][]string
+}
+
+func (f *fakeRedis) serve(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.handle(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		cmd, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, cmd)
+		f.mu.Unlock()
+
+		reply := "+OK\r\n"
+		switch strings.ToUpper(cmd[0]) {
+		case "HELLO":
+			// Сервер без RESP3: клиент переходит на AUTH
+			reply = "-ERR unknown command 'HELLO'\r\n"
+		case "PING":
+			reply = "+PONG\r\n"
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) received(name string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, cmd := range f.commands {
+		if strings.EqualFold(cmd[0], name) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimSuffix(arg, "\r\n"))
+	}
+	return args, nil
+}
+
+func TestNewRedis_StandaloneWithACL(t *testing.T) {
+	fake := &fakeRedis{}
+	cfg := config.Default().Redis
+	cfg.Addr = fake.serve(t)
+	cfg.Username = "gateway"
+	cfg.Password = "secret"
+
+	rdb, err := NewRedis(context.Background(), cfg)
+	require.NoError(t, err)
+	defer rdb.Close()
+
+	assert.IsType(t, &redis.Client{}, rdb)
+	assert.Equal(t, []string{"auth", "gateway", "secret"}, fake.received("auth"))
+	assert.NotNil(t, fake.received("ping"))
+}
+
+func TestNewRedis_Unreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	cfg := config.Default().Redis
+	cfg.Addr = addr
+	cfg.DialTimeout = 100 * time.Millisecond
+	cfg.ReadTimeout = 100 * time.Millisecond
+
+	_, err = NewRedis(context.Background(), cfg)
+	assert.Error(t, err)
+}
+
+func TestNewClient_Modes(t *testing.T) {
+	opts := &redis.UniversalOptions{Addrs: []string{"127.0.0.1:1"}, MasterName: "mymaster"}
+
+	for mode, want := range map[string]redis.UniversalClient{
+		config.RedisStandalone: &redis.Client{},
+		config.RedisSentinel:   &redis.Client{},
+		config.RedisCluster:    &redis.ClusterClient{},
+	} {
+		rdb := newClient(mode, opts)
+		assert.IsType(t, want, rdb, mode)
+		_ = rdb.Close()
+	}
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	redis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"messenger_frontend/internal/tracing"
+)
+
+// tracingHook открывает клиентский спан на каждую команду и на каждый pipeline.
+// Аргументы команд в спан не попадают: в них лежат токены сессий.
+type tracingHook struct{}
+
+func (tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := startSpan(ctx, "redis "+cmd.Name(), cmd.Name())
+		defer span.End()
+		err := next(ctx, cmd)
+		recordError(span, cmd.Err())
+		return err
+	}
+}
+
+func (tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = cmd.Name()
+		}
+		ctx, span := startSpan(ctx, "redis pipeline", strings.Join(names, " "))
+		defer span.End()
+		err := next(ctx, cmds)
+		recordError(span, err)
+		return err
+	}
+}
+
+func startSpan(ctx context.Context, name, operation string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationName(operation)),
+	)
+}
+
+// recordError не считает промах по ключу ошибкой.
+func recordError(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
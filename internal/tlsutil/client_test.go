@@ -0,0 +1,187 @@
+package tlsutil
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue выпускает сертификат для name и возвращает его вместе с путями к PEM-файлам.
+func (ca *testCA) issue(t *testing.T, dir, name string, usage x509.ExtKeyUsage) (tls.Certificate, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	return pair, certFile, keyFile
+}
+
+// serveMTLS запускает TLS-сервер, требующий клиентский сертификат от ca.
+func serveMTLS(t *testing.T, ca *testCA, serverCert tls.Certificate) string {
+	t.Helper()
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func handshake(addr string, cfg *tls.Config) error {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", addr, cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// В TLS 1.3 отказ сервера в клиентском сертификате приходит только при первом чтении.
+	_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	_, err = conn.Read(make([]byte, 1))
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return nil
+	}
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+func TestNewClientConfig_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0o600))
+
+	serverCert, _, _ := ca.issue(t, dir, "dialogs.internal", x509.ExtKeyUsageServerAuth)
+	_, clientCertFile, clientKeyFile := ca.issue(t, dir, "gateway", x509.ExtKeyUsageClientAuth)
+	addr := serveMTLS(t, ca, serverCert)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg, err := NewClientConfig(ctx, ClientOptions{
+		CAFile:         caFile,
+		CertFile:       clientCertFile,
+		KeyFile:        clientKeyFile,
+		ServerName:     "dialogs.internal",
+		ReloadInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	assert.NoError(t, handshake(addr, cfg))
+
+	// Другое имя сервера не проходит проверку.
+	cfg, err = NewClientConfig(ctx, ClientOptions{
+		CAFile:         caFile,
+		CertFile:       clientCertFile,
+		KeyFile:        clientKeyFile,
+		ServerName:     "users.internal",
+		ReloadInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	assert.Error(t, handshake(addr, cfg))
+}
+
+func TestNewClientConfig_UntrustedServer(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	otherCA := newTestCA(t)
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, otherCA.pem, 0o600))
+
+	serverCert, _, _ := ca.issue(t, dir, "dialogs.internal", x509.ExtKeyUsageServerAuth)
+	_, clientCertFile, clientKeyFile := ca.issue(t, dir, "gateway", x509.ExtKeyUsageClientAuth)
+	addr := serveMTLS(t, ca, serverCert)
+
+	cfg, err := NewClientConfig(context.Background(), ClientOptions{
+		CAFile:         caFile,
+		CertFile:       clientCertFile,
+		KeyFile:        clientKeyFile,
+		ServerName:     "dialogs.internal",
+		ReloadInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	assert.Error(t, handshake(addr, cfg))
+}
+
+func TestNewClientConfig_MissingFilesFailFast(t *testing.T) {
+	_, err := NewClientConfig(context.Background(), ClientOptions{CAFile: "/nonexistent/ca.pem", ReloadInterval: time.Minute})
+	assert.Error(t, err)
+
+	_, err = NewClientConfig(context.Background(), ClientOptions{CertFile: "/nonexistent/tls.crt", ReloadInterval: time.Minute})
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+var versions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseVersion разбирает минимальную версию TLS: "1.2" или "1.3". Пустая строка означает 1.2.
+func ParseVersion(s string) (uint16, error) {
+	if s == "" {
+		return tls.VersionTLS12, nil
+	}
+	v, ok := versions[s]
+	if !ok {
+		return 0, fmt.Errorf("unsupported TLS version %q (want 1.2 or 1.3)", s)
+	}
+	return v, nil
+}
+
+// ParseCipherSuites переводит имена наборов шифров (например, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256)
+// в идентификаторы. Небезопасные наборы из tls.InsecureCipherSuites не принимаются.
+// Для TLS 1.3 список не применяется: Go всегда использует собственный набор.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, cs := range tls.CipherSuites() {
+		known[cs.Name] = cs.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,22 @@
+package tlsutil
+
+import (
+	"net"
+	"net/http"
+)
+
+// RedirectHandler перенаправляет запросы на HTTPS-listener с адресом httpsAddr,
+// сохраняя хост, путь и query. Используется 308, чтобы клиенты повторили метод и тело.
+func RedirectHandler(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
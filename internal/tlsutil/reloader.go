@@ -0,0 +1,116 @@
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertReloader держит пару сертификат/ключ и перечитывает её, когда файлы на диске меняются.
+// Если новая пара не загружается, продолжает отдавать прежний сертификат.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewCertReloader загружает сертификат сразу, чтобы ошибки конфигурации обнаруживались при старте.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate %s: %w", r.certFile, err)
+	}
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+// Watch проверяет файлы каждые interval и перечитывает их при изменении, пока ctx не отменён.
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	watch(ctx, interval, []string{r.certFile, r.keyFile}, r.loadedAt, r.Reload)
+}
+
+func (r *CertReloader) loadedAt() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.modTime
+}
+
+func (r *CertReloader) certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+// GetCertificate подходит для tls.Config.GetCertificate на стороне сервера.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.certificate(), nil
+}
+
+// GetClientCertificate подходит для tls.Config.GetClientCertificate на стороне клиента.
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.certificate(), nil
+}
+
+// watch вызывает reload, когда время изменения одного из files становится позже loadedAt.
+func watch(ctx context.Context, interval time.Duration, files []string, loadedAt func() time.Time, reload func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modTime, err := latestModTime(files...)
+		if err != nil {
+			slog.Warn("tls: stat failed", "error", err)
+			continue
+		}
+		if !modTime.After(loadedAt()) {
+			continue
+		}
+		if err := reload(); err != nil {
+			slog.Warn("tls: reload failed, keeping previous version", "file", files[0], "error", err)
+			continue
+		}
+		slog.Info("tls: reloaded", "file", files[0])
+	}
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
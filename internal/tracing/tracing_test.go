@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"messenger_frontend/internal/config"
+)
+
+func TestSetup_FileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+	cfg := config.Default().Tracing
+	cfg.Exporter = config.TracingFile
+	cfg.File = path
+
+	shutdown, err := Setup(context.Background(), cfg, "test")
+	require.NoError(t, err)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	_, span := Tracer().Start(context.Background(), "GET /dialog/user")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"GET /dialog/user"`)
+	assert.Contains(t, string(data), "messenger-gateway")
+}
+
+func TestSampler(t *testing.T) {
+	sampled := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	params := sdktrace.SamplingParameters{
+		ParentContext: trace.ContextWithRemoteSpanContext(context.Background(), sampled),
+		TraceID:       sampled.TraceID(),
+	}
+	cfg := config.TracingConfig{SampleRatio: 0, ParentBased: true}
+
+	// Решение клиента сохраняется, даже если шлюз сам трассы не записывает
+	assert.Equal(t, sdktrace.RecordAndSample, Sampler(cfg).ShouldSample(params).Decision)
+	cfg.ParentBased = false
+	assert.Equal(t, sdktrace.Drop, Sampler(cfg).ShouldSample(params).Decision)
+}
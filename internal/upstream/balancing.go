@@ -0,0 +1,218 @@
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/balancer/leastrequest" // регистрирует least_request_experimental
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/health" // клиентская проверка grpc.health.v1 для healthCheckConfig
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+// Политики балансировки между репликами.
+const (
+	RoundRobin   = "round_robin"
+	LeastRequest = "least_request"
+	PickFirst    = "pick_first"
+)
+
+const healthWatchMethod = "/grpc.health.v1.Health/Watch"
+
+type BalancingOptions struct {
+	// Policy — round_robin, least_request или pick_first.
+	Policy string
+	// HealthCheck включает проверку реплик через grpc.health.v1; сервис без него считается здоровым.
+	HealthCheck bool
+	// Refresh — как часто перечитывать DNS и файл со списком адресов.
+	Refresh time.Duration
+	Outlier OutlierConfig
+}
+
+// Balancing связывает обнаружение реплик, исключение неисправных и статистику по каждой из них.
+// Одно значение обслуживает один upstream и переживает смену адреса через Conn.Redial.
+type Balancing struct {
+	opts     BalancingOptions
+	outliers *OutlierDetector
+
+	mu        sync.Mutex
+	endpoints map[string]*endpointStats
+}
+
+func NewBalancing(opts BalancingOptions) *Balancing {
+	return &Balancing{
+		opts:      opts,
+		outliers:  NewOutlierDetector(opts.Outlier),
+		endpoints: make(map[string]*endpointStats),
+	}
+}
+
+// DialOptions возвращает резолверы схем dns, static и file, сервисную конфигурацию
+// с политикой балансировки и обработчик статистики.
+func (b *Balancing) DialOptions() []grpc.DialOption {
+	builders := make([]resolver.Builder, 0, 3)
+	for scheme, lookup := range map[string]lookupFunc{
+		SchemeDNS:    lookupDNS,
+		SchemeStatic: lookupStatic,
+		SchemeFile:   lookupFile,
+	} {
+		builders = append(builders, &discoveryBuilder{
+			scheme:   scheme,
+			lookup:   lookup,
+			refresh:  b.opts.Refresh,
+			outliers: b.outliers,
+		})
+	}
+	return []grpc.DialOption{
+		grpc.WithResolvers(builders...),
+		grpc.WithDefaultServiceConfig(serviceConfig(b.opts)),
+		grpc.WithStatsHandler(b),
+	}
+}
+
+func serviceConfig(opts BalancingOptions) string {
+	policy := `{"round_robin":{}}`
+	switch opts.Policy {
+	case LeastRequest:
+		policy = `{"least_request_experimental":{"choiceCount":2}}`
+	case PickFirst:
+		policy = `{"pick_first":{}}`
+	}
+	cfg := fmt.Sprintf(`{"loadBalancingConfig":[%s]`, policy)
+	if opts.HealthCheck {
+		cfg += `,"healthCheckConfig":{"serviceName":""}`
+	}
+	return cfg + "}"
+}
+
+// EndpointStats — статистика одной реплики (подключения балансировщика).
+type EndpointStats struct {
+	Connections int64 `json:"connections"`
+	InFlight    int64 `json:"in_flight"`
+	Requests    int64 `json:"requests"`
+	Failures    int64 `json:"failures"`
+	// LatencyMsTotal — суммарная длительность вызовов; вместе с Requests даёт среднее.
+	LatencyMsTotal int64 `json:"latency_ms_total"`
+	Ejected        bool  `json:"ejected"`
+}
+
+type endpointStats struct {
+	connections atomic.Int64
+	inflight    atomic.Int64
+	requests    atomic.Int64
+	failures    atomic.Int64
+	latencyMs   atomic.Int64
+}
+
+func (b *Balancing) endpoint(addr string) *endpointStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.endpoints[addr]
+	if !ok {
+		e = &endpointStats{}
+		b.endpoints[addr] = e
+	}
+	return e
+}
+
+// Stats возвращает статистику по всем репликам, к которым шлюз подключался.
+func (b *Balancing) Stats() map[string]EndpointStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make(map[string]EndpointStats, len(b.endpoints))
+	for addr, e := range b.endpoints {
+		out[addr] = EndpointStats{
+			Connections:    e.connections.Load(),
+			InFlight:       e.inflight.Load(),
+			Requests:       e.requests.Load(),
+			Failures:       e.failures.Load(),
+			LatencyMsTotal: e.latencyMs.Load(),
+			Ejected:        b.outliers.Ejected(addr),
+		}
+	}
+	return out
+}
+
+// isHostFailure отделяет ошибки, говорящие о неисправности реплики, от ошибок приложения
+// вроде NotFound или Internal. DeadlineExceeded засчитывается реплике, только если контекст
+// вызова ещё жив: истёкший дедлайн маршрута или ушедший клиент о здоровье реплики не говорят.
+func isHostFailure(ctx context.Context, err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return true
+	case codes.DeadlineExceeded:
+		return ctx.Err() == nil
+	}
+	return false
+}
+
+type connKey struct{}
+
+type rpcKey struct{}
+
+// rpcState хранит адрес реплики, выбранной для вызова: он известен только после OutHeader.
+type rpcState struct {
+	endpoint *endpointStats
+	addr     string
+}
+
+func (b *Balancing) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	if info.RemoteAddr == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, connKey{}, info.RemoteAddr.String())
+}
+
+func (b *Balancing) HandleConn(ctx context.Context, s stats.ConnStats) {
+	addr, ok := ctx.Value(connKey{}).(string)
+	if !ok {
+		return
+	}
+	switch s.(type) {
+	case *stats.ConnBegin:
+		b.endpoint(addr).connections.Add(1)
+	case *stats.ConnEnd:
+		b.endpoint(addr).connections.Add(-1)
+	}
+}
+
+func (b *Balancing) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	if info.FullMethodName == healthWatchMethod {
+		return ctx
+	}
+	return context.WithValue(ctx, rpcKey{}, &rpcState{})
+}
+
+func (b *Balancing) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	state, ok := ctx.Value(rpcKey{}).(*rpcState)
+	if !ok {
+		return
+	}
+	switch s := s.(type) {
+	case *stats.OutHeader:
+		if s.RemoteAddr == nil {
+			return
+		}
+		state.addr = s.RemoteAddr.String()
+		state.endpoint = b.endpoint(state.addr)
+		state.endpoint.inflight.Add(1)
+	case *stats.End:
+		if state.endpoint == nil {
+			return
+		}
+		failed := isHostFailure(ctx, s.Error)
+		state.endpoint.inflight.Add(-1)
+		state.endpoint.requests.Add(1)
+		state.endpoint.latencyMs.Add(s.EndTime.Sub(s.BeginTime).Milliseconds())
+		if failed {
+			state.endpoint.failures.Add(1)
+		}
+		b.outliers.Record(state.addr, failed)
+	}
+}
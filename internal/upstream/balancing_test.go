@@ -0,0 +1,172 @@
+package upstream
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// startReplica запускает gRPC-сервер, который отвечает на любой метод успехом или ошибкой code.
+func startReplica(t *testing.T, code codes.Code) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+		if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+			return err
+		}
+		if code != codes.OK {
+			return status.Error(code, "replica failure")
+		}
+		return stream.SendMsg(&emptypb.Empty{})
+	}))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+	return ln.Addr().String()
+}
+
+func call(c *Conn) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return c.Invoke(ctx, "/test.Replica/Call", &emptypb.Empty{}, &emptypb.Empty{})
+}
+
+func TestBalancing_RoundRobinAcrossStaticEndpoints(t *testing.T) {
+	a, b := startReplica(t, codes.OK), startReplica(t, codes.OK)
+	balancing := NewBalancing(BalancingOptions{Policy: RoundRobin, HealthCheck: true, Refresh: time.Minute})
+	c, err := Dial("static:///"+a+","+b, append(balancing.DialOptions(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+	require.NoError(t, err)
+	defer c.Close()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, call(c))
+	}
+	stats := balancing.Stats()
+	assert.Positive(t, stats[a].Requests)
+	assert.Positive(t, stats[b].Requests)
+	assert.Equal(t, int64(20), stats[a].Requests+stats[b].Requests)
+	assert.Equal(t, int64(1), stats[a].Connections)
+}
+
+func TestBalancing_EjectsFailingReplica(t *testing.T) {
+	good, bad := startReplica(t, codes.OK), startReplica(t, codes.Unavailable)
+	balancing := NewBalancing(BalancingOptions{
+		Policy:  RoundRobin,
+		Refresh: time.Minute,
+		Outlier: OutlierConfig{ConsecutiveFailures: 2, BaseEjectionTime: time.Minute, MaxEjectionPercent: 50},
+	})
+	c, err := Dial("static:///"+good+","+bad, append(balancing.DialOptions(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+	require.NoError(t, err)
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		_ = call(c)
+	}
+	require.Eventually(t, func() bool { return balancing.Stats()[bad].Ejected }, time.Second, 10*time.Millisecond)
+
+	// Резолвер убрал реплику из выдачи: после переключения балансировщика вызовы проходят
+	assert.Eventually(t, func() bool { return call(c) == nil && call(c) == nil && call(c) == nil },
+		time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(2), balancing.Stats()[bad].Failures)
+}
+
+func TestBalancing_ExpiredRequestDeadlineIsNotHostFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+		if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+			return err
+		}
+		// Реплика здорова, но отвечает дольше, чем готов ждать клиент
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-stream.Context().Done():
+		}
+		return stream.SendMsg(&emptypb.Empty{})
+	}))
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+	slow := ln.Addr().String()
+
+	balancing := NewBalancing(BalancingOptions{
+		Policy:  RoundRobin,
+		Refresh: time.Minute,
+		Outlier: OutlierConfig{ConsecutiveFailures: 2, BaseEjectionTime: time.Minute, MaxEjectionPercent: 100},
+	})
+	c, err := Dial("static:///"+slow, append(balancing.DialOptions(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+	require.NoError(t, err)
+	defer c.Close()
+
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		err := c.Invoke(ctx, "/test.Replica/Call", &emptypb.Empty{}, &emptypb.Empty{})
+		cancel()
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	}
+	stats := balancing.Stats()[slow]
+	assert.Equal(t, int64(5), stats.Requests)
+	assert.Zero(t, stats.Failures)
+	assert.False(t, stats.Ejected)
+}
+
+func TestIsHostFailure(t *testing.T) {
+	live := context.Background()
+	expired, cancel := context.WithTimeout(live, 0)
+	defer cancel()
+
+	assert.True(t, isHostFailure(live, status.Error(codes.Unavailable, "")))
+	assert.True(t, isHostFailure(live, status.Error(codes.DeadlineExceeded, "")))
+	assert.False(t, isHostFailure(expired, status.Error(codes.DeadlineExceeded, "")))
+	assert.False(t, isHostFailure(live, status.Error(codes.Internal, "")))
+	assert.False(t, isHostFailure(live, status.Error(codes.Unknown, "")))
+	assert.False(t, isHostFailure(live, nil))
+}
+
+func TestOutlierDetector_RespectsMaxEjectionPercent(t *testing.T) {
+	o := NewOutlierDetector(OutlierConfig{ConsecutiveFailures: 1, BaseEjectionTime: time.Minute, MaxEjectionPercent: 50})
+	addrs := []string{"10.0.0.1:1", "10.0.0.2:1", "10.0.0.3:1", "10.0.0.4:1"}
+	o.Filter(addrs)
+
+	for _, addr := range addrs {
+		o.Record(addr, true)
+	}
+	assert.Len(t, o.Filter(addrs), 2)
+
+	// После срока исключения адрес возвращается, а повторное исключение длится вдвое дольше
+	now := time.Now().Add(2 * time.Minute)
+	o.now = func() time.Time { return now }
+	assert.Len(t, o.Filter(addrs), 4)
+	o.Record(addrs[0], true)
+	now = now.Add(90 * time.Second)
+	assert.True(t, o.Ejected(addrs[0]))
+}
+
+func TestLookupFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dialogs.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# реплики\n10.0.0.2:9001\n\n10.0.0.1:9001\n"), 0o600))
+
+	addrs, err := lookupFile(context.Background(), path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:9001", "10.0.0.2:9001"}, addrs)
+
+	require.NoError(t, os.WriteFile(path, []byte("not-an-address\n"), 0o600))
+	_, err = lookupFile(context.Background(), path)
+	assert.Error(t, err)
+}
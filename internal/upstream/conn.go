@@ -0,0 +1,122 @@
+package upstream
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// generation — одно физическое подключение и счётчик вызовов, которые через него идут.
+type generation struct {
+	conn     *grpc.ClientConn
+	target   string
+	inflight sync.WaitGroup
+}
+
+// Conn — gRPC-подключение, адрес которого можно сменить без остановки шлюза.
+// Новые вызовы сразу идут через новое подключение, а старое закрывается,
+// когда завершатся начатые через него вызовы.
+type Conn struct {
+	opts []grpc.DialOption
+
+	mu  sync.RWMutex
+	cur *generation
+}
+
+func Dial(target string, opts ...grpc.DialOption) (*Conn, error) {
+	c := &Conn{opts: opts}
+	g, err := c.dial(target)
+	if err != nil {
+		return nil, err
+	}
+	c.cur = g
+	return c, nil
+}
+
+func (c *Conn) dial(target string) (*generation, error) {
+	conn, err := grpc.DialContext(context.Background(), target, c.opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &generation{conn: conn, target: target}, nil
+}
+
+func (c *Conn) acquire() *generation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	g := c.cur
+	g.inflight.Add(1)
+	return g
+}
+
+func (c *Conn) current() *generation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cur
+}
+
+func (c *Conn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	g := c.acquire()
+	defer g.inflight.Done()
+	return g.conn.Invoke(ctx, method, args, reply, opts...)
+}
+
+func (c *Conn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	g := c.acquire()
+	stream, err := g.conn.NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		g.inflight.Done()
+		return nil, err
+	}
+	go func() {
+		<-stream.Context().Done()
+		g.inflight.Done()
+	}()
+	return stream, nil
+}
+
+// Target возвращает адрес текущего подключения.
+func (c *Conn) Target() string {
+	return c.current().target
+}
+
+// Redial переключает новые вызовы на target. Если адрес не изменился, ничего не делает.
+func (c *Conn) Redial(target string) error {
+	if target == c.Target() {
+		return nil
+	}
+	g, err := c.dial(target)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	old := c.cur
+	c.cur = g
+	c.mu.Unlock()
+
+	go func() {
+		old.inflight.Wait()
+		_ = old.conn.Close()
+	}()
+	return nil
+}
+
+func (c *Conn) GetState() connectivity.State {
+	return c.current().conn.GetState()
+}
+
+func (c *Conn) Connect() {
+	c.current().conn.Connect()
+}
+
+func (c *Conn) WaitForStateChange(ctx context.Context, sourceState connectivity.State) bool {
+	return c.current().conn.WaitForStateChange(ctx, sourceState)
+}
+
+// Close закрывает текущее подключение, не дожидаясь вызовов в обработке.
+func (c *Conn) Close() error {
+	return c.current().conn.Close()
+}
@@ -0,0 +1,43 @@
+package upstream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestConn_RedialDrainsOldConnection(t *testing.T) {
+	c, err := Dial("passthrough:///127.0.0.1:1", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer c.Close()
+
+	// Вызов, начатый до смены адреса, удерживает старое подключение.
+	old := c.acquire()
+
+	require.NoError(t, c.Redial("passthrough:///127.0.0.1:2"))
+	assert.Equal(t, "passthrough:///127.0.0.1:2", c.Target())
+	assert.NotSame(t, old, c.current())
+
+	time.Sleep(20 * time.Millisecond)
+	assert.NotEqual(t, connectivity.Shutdown, old.conn.GetState())
+
+	old.inflight.Done()
+	assert.Eventually(t, func() bool {
+		return old.conn.GetState() == connectivity.Shutdown
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestConn_RedialSameTargetIsNoop(t *testing.T) {
+	c, err := Dial("passthrough:///127.0.0.1:1", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer c.Close()
+
+	g := c.current()
+	require.NoError(t, c.Redial("passthrough:///127.0.0.1:1"))
+	assert.Same(t, g, c.current())
+}
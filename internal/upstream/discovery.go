@@ -0,0 +1,199 @@
+package upstream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// Схемы адресов, которые понимает шлюз:
+//
+//	dns:///dialog_service:9001          — все A/AAAA-записи имени, перечитываются каждые refresh
+//	static:///10.0.0.1:9001,10.0.0.2:9001 — фиксированный список
+//	file:///etc/gateway/dialogs.txt     — по адресу в строке, файл перечитывается каждые refresh
+const (
+	SchemeDNS    = "dns"
+	SchemeStatic = "static"
+	SchemeFile   = "file"
+)
+
+type lookupFunc func(ctx context.Context, endpoint string) ([]string, error)
+
+// discoveryBuilder создаёт резолверы одной схемы. Выдача проходит через OutlierDetector,
+// поэтому исключённые реплики пропадают из балансировки и возвращаются по истечении срока.
+type discoveryBuilder struct {
+	scheme   string
+	lookup   lookupFunc
+	refresh  time.Duration
+	outliers *OutlierDetector
+}
+
+func (b *discoveryBuilder) Scheme() string { return b.scheme }
+
+func (b *discoveryBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	endpoint := strings.TrimPrefix(target.URL.Path, "/")
+	if b.scheme == SchemeFile {
+		endpoint = target.URL.Path
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("%s: empty target", b.scheme)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &discoveryResolver{
+		builder:    b,
+		endpoint:   endpoint,
+		cc:         cc,
+		cancel:     cancel,
+		resolveNow: make(chan struct{}, 1),
+	}
+	go r.run(ctx)
+	return r, nil
+}
+
+type discoveryResolver struct {
+	builder    *discoveryBuilder
+	endpoint   string
+	cc         resolver.ClientConn
+	cancel     context.CancelFunc
+	resolveNow chan struct{}
+}
+
+func (r *discoveryResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.resolveNow <- struct{}{}:
+	default:
+	}
+}
+
+func (r *discoveryResolver) Close() { r.cancel() }
+
+func (r *discoveryResolver) run(ctx context.Context) {
+	ticker := time.NewTicker(r.builder.refresh)
+	defer ticker.Stop()
+
+	var discovered, pushed []string
+	for {
+		addrs, err := r.builder.lookup(ctx, r.endpoint)
+		if err == nil && len(addrs) == 0 {
+			err = fmt.Errorf("%s: no addresses for %s", r.builder.scheme, r.endpoint)
+		}
+		switch {
+		case err == nil:
+			discovered = addrs
+		case len(discovered) == 0:
+			r.cc.ReportError(err)
+		default:
+			// Временная ошибка: продолжаем работать с последним известным списком
+			slog.Warn("upstream discovery failed, keeping previous addresses", "target", r.endpoint, "error", err)
+		}
+
+		var changed <-chan struct{}
+		if r.builder.outliers != nil {
+			changed = r.builder.outliers.Changed()
+		}
+		if len(discovered) > 0 {
+			next := discovered
+			if r.builder.outliers != nil {
+				next = r.builder.outliers.Filter(discovered)
+			}
+			if !slices.Equal(next, pushed) {
+				if err := r.cc.UpdateState(resolver.State{Addresses: toAddresses(next)}); err == nil {
+					pushed = next
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.resolveNow:
+		case <-changed:
+		}
+	}
+}
+
+func toAddresses(addrs []string) []resolver.Address {
+	out := make([]resolver.Address, len(addrs))
+	for i, a := range addrs {
+		out[i] = resolver.Address{Addr: a}
+	}
+	return out
+}
+
+func lookupStatic(ctx context.Context, endpoint string) ([]string, error) {
+	var addrs []string
+	for _, a := range strings.Split(endpoint, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			addrs = append(addrs, a)
+		}
+	}
+	return resolveHosts(ctx, addrs)
+}
+
+// resolveHosts заменяет имена на IP-адреса: статистика вызовов знает реплику только по IP,
+// и исключать из выдачи можно только то, что с ним совпадает.
+func resolveHosts(ctx context.Context, endpoints []string) ([]string, error) {
+	var addrs []string
+	for _, endpoint := range endpoints {
+		resolved, err := lookupDNS(ctx, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, resolved...)
+	}
+	slices.Sort(addrs)
+	return slices.Compact(addrs), nil
+}
+
+func lookupDNS(ctx context.Context, endpoint string) ([]string, error) {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return []string{endpoint}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.JoinHostPort(ip, port)
+	}
+	slices.Sort(addrs)
+	return addrs, nil
+}
+
+// lookupFile читает адреса по одному в строке; пустые строки и комментарии (#) пропускаются.
+func lookupFile(ctx context.Context, path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(line); err != nil {
+			return nil, fmt.Errorf("%s: bad address %q: %w", path, line, err)
+		}
+		addrs = append(addrs, line)
+	}
+	return resolveHosts(ctx, addrs)
+}
@@ -0,0 +1,109 @@
+package upstream
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"messenger_frontend/internal/logging"
+	"messenger_frontend/internal/metrics"
+	"messenger_frontend/internal/tracing"
+)
+
+// requestIDMetadata — ключ метаданных gRPC, в котором upstream получает X-Request-ID.
+const requestIDMetadata = "x-request-id"
+
+// UnaryClientInterceptor передаёт идентификатор запроса и контекст трассировки (traceparent)
+// в upstream, открывает клиентский спан, считает метрики вызовов и пишет в debug-лог каждый
+// вызов с репликой, кодом ответа и длительностью.
+func UnaryClientInterceptor(name string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		service, rpc := splitMethod(method)
+		ctx, span := tracing.Tracer().Start(ctx, strings.TrimPrefix(method, "/"),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.RPCSystemGRPC,
+				semconv.RPCService(service),
+				semconv.RPCMethod(rpc),
+				semconv.PeerService(name),
+			),
+		)
+		defer span.End()
+
+		md, _ := metadata.FromOutgoingContext(ctx)
+		md = md.Copy()
+		otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+		if id := logging.RequestID(ctx); id != "" {
+			md.Set(requestIDMetadata, id)
+		}
+		ctx = metadata.NewOutgoingContext(ctx, md)
+
+		var p peer.Peer
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p))...)
+
+		elapsed := time.Since(start)
+		logging.ObserveUpstream(ctx, name, elapsed)
+		st := status.Convert(err)
+		code := st.Code().String()
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(st.Code())))
+		if p.Addr != nil {
+			span.SetAttributes(semconv.NetworkPeerAddress(p.Addr.String()))
+		}
+		if err != nil {
+			span.SetStatus(otelcodes.Error, st.Message())
+		}
+		metrics.GRPCClientRequests.WithLabelValues(name, method, code).Inc()
+		metrics.GRPCClientDuration.WithLabelValues(name, method, code).Observe(elapsed.Seconds())
+
+		attrs := []any{
+			"upstream", name,
+			"grpc_method", method,
+			"code", code,
+			"latency_ms", elapsed.Milliseconds(),
+		}
+		if p.Addr != nil {
+			attrs = append(attrs, "endpoint", p.Addr.String())
+		}
+		slog.DebugContext(ctx, "upstream call", attrs...)
+		return err
+	}
+}
+
+// splitMethod разбирает полное имя "/package.Service/Method".
+func splitMethod(method string) (service, rpc string) {
+	service, rpc, _ = strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	return service, rpc
+}
+
+// metadataCarrier позволяет пропагатору OpenTelemetry писать в метаданные gRPC.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,44 @@
+package upstream
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"messenger_frontend/internal/logging"
+)
+
+func TestUnaryClientInterceptor_PropagatesContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var md metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-custom", "kept")
+	err := UnaryClientInterceptor("dialogs")(ctx, "/dialog.DialogService/SendMessage", nil, nil, nil, invoker)
+	assert.NoError(t, err)
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "dialog.DialogService/SendMessage", spans[0].Name())
+		traceparent := md.Get("traceparent")
+		if assert.Len(t, traceparent, 1) {
+			assert.True(t, strings.Contains(traceparent[0], spans[0].SpanContext().SpanID().String()))
+		}
+	}
+	assert.Equal(t, []string{"req-1"}, md.Get(requestIDMetadata))
+	assert.Equal(t, []string{"kept"}, md.Get("x-custom"))
+}
@@ -0,0 +1,138 @@
+package upstream
+
+import (
+	"sync"
+	"time"
+)
+
+// OutlierConfig — правила исключения реплик, которые подряд отвечают ошибками.
+type OutlierConfig struct {
+	// ConsecutiveFailures — сколько ошибок подряд приводят к исключению; 0 отключает проверку.
+	ConsecutiveFailures int
+	// BaseEjectionTime — срок первого исключения; каждое следующее длится на столько же дольше.
+	BaseEjectionTime time.Duration
+	// MaxEjectionPercent — какую долю реплик можно исключить одновременно.
+	MaxEjectionPercent int
+}
+
+type hostState struct {
+	failures     int
+	ejections    int
+	ejectedUntil time.Time
+}
+
+// OutlierDetector считает ошибки по адресам и решает, какие адреса временно не отдавать балансировщику.
+type OutlierDetector struct {
+	cfg OutlierConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	hosts   map[string]*hostState
+	known   int
+	changed chan struct{}
+}
+
+func NewOutlierDetector(cfg OutlierConfig) *OutlierDetector {
+	return &OutlierDetector{
+		cfg:     cfg,
+		now:     time.Now,
+		hosts:   make(map[string]*hostState),
+		changed: make(chan struct{}),
+	}
+}
+
+// Changed возвращает канал, который закрывается при следующем исключении или возвращении адреса.
+func (o *OutlierDetector) Changed() <-chan struct{} {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.changed
+}
+
+func (o *OutlierDetector) notifyLocked() {
+	close(o.changed)
+	o.changed = make(chan struct{})
+}
+
+func (o *OutlierDetector) notify() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.notifyLocked()
+}
+
+// Record учитывает результат вызова к addr. Успех сбрасывает счётчик ошибок подряд.
+func (o *OutlierDetector) Record(addr string, failed bool) {
+	if o.cfg.ConsecutiveFailures <= 0 {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	h := o.host(addr)
+	if !failed {
+		h.failures = 0
+		return
+	}
+	h.failures++
+	now := o.now()
+	if h.failures < o.cfg.ConsecutiveFailures || now.Before(h.ejectedUntil) {
+		return
+	}
+	if o.ejectedLocked(now)+1 > o.known*o.cfg.MaxEjectionPercent/100 {
+		return
+	}
+
+	h.failures = 0
+	h.ejections++
+	d := o.cfg.BaseEjectionTime * time.Duration(h.ejections)
+	h.ejectedUntil = now.Add(d)
+	o.notifyLocked()
+	time.AfterFunc(d, o.notify)
+}
+
+func (o *OutlierDetector) host(addr string) *hostState {
+	h, ok := o.hosts[addr]
+	if !ok {
+		h = &hostState{}
+		o.hosts[addr] = h
+	}
+	return h
+}
+
+func (o *OutlierDetector) ejectedLocked(now time.Time) int {
+	n := 0
+	for _, h := range o.hosts {
+		if now.Before(h.ejectedUntil) {
+			n++
+		}
+	}
+	return n
+}
+
+// Filter убирает исключённые адреса из addrs и запоминает размер пула.
+// Если исключены все адреса, возвращает исходный список: лучше попытаться, чем отказать сразу.
+func (o *OutlierDetector) Filter(addrs []string) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.known = len(addrs)
+	now := o.now()
+	healthy := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if h, ok := o.hosts[addr]; ok && now.Before(h.ejectedUntil) {
+			continue
+		}
+		healthy = append(healthy, addr)
+	}
+	if len(healthy) == 0 {
+		return addrs
+	}
+	return healthy
+}
+
+// Ejected сообщает, исключён ли addr сейчас.
+func (o *OutlierDetector) Ejected(addr string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	h, ok := o.hosts[addr]
+	return ok && o.now().Before(h.ejectedUntil)
+}